@@ -0,0 +1,202 @@
+//protoc-gen-impackets generates the packets wrappers for every message in
+//proto/ that carries the (packet_type) option:
+//
+//	protoc -I=proto --impackets_out=packets proto/*.proto
+//
+//For a message LoginReq with option (packet_type) = 4 it emits
+//loginreq.impackets.go, declaring the Loginreq constant, its registration
+//and the LoginreqPacket ControlPacket, together with
+//loginreq.impackets_test.go holding a round-trip test. The generated code
+//relies on unexported helpers of the packets package and therefore has to
+//be generated into it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"strings"
+)
+
+const (
+	packetsImportPath  = protogen.GoImportPath("github.com/bitstreamstudio/im-packets/packets")
+	fmtImportPath      = protogen.GoImportPath("fmt")
+	ioImportPath       = protogen.GoImportPath("io")
+	bytesImportPath    = protogen.GoImportPath("bytes")
+	testingImportPath  = protogen.GoImportPath("testing")
+	protobufImportPath = protogen.GoImportPath("github.com/golang/protobuf/proto")
+)
+
+func main() {
+	var flags flag.FlagSet
+	protocolImportPath := flags.String("protocol", "github.com/bitstreamstudio/im-packets/protocol", "import path of the generated protobuf messages")
+	protogen.Options{ParamFunc: flags.Set}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			var packets []*packet
+			for _, m := range f.Messages {
+				p, err := newPacket(m, protogen.GoImportPath(*protocolImportPath))
+				if err != nil {
+					return err
+				}
+				if p != nil {
+					packets = append(packets, p)
+				}
+			}
+			if len(packets) == 0 {
+				continue
+			}
+			genPackets(gen, f, packets)
+			genPacketsTest(gen, f, packets)
+		}
+		return nil
+	})
+}
+
+//packet describes the ControlPacket generated for a single message
+type packet struct {
+	code     uint32
+	message  *protogen.Message
+	ident    protogen.GoIdent
+	constant string
+	name     string
+	receiver string
+}
+
+//newPacket returns the packet generated for m, or nil if m carries no
+//(packet_type) option
+func newPacket(m *protogen.Message, protocolImportPath protogen.GoImportPath) (*packet, error) {
+	if !proto.HasExtension(m.Desc.Options(), protocol.E_PacketType) {
+		return nil, nil
+	}
+	code := proto.GetExtension(m.Desc.Options(), protocol.E_PacketType).(uint32)
+	if code == 0 || code > 0xFF {
+		return nil, fmt.Errorf("%s: packet_type %d out of range 1-255", m.Desc.FullName(), code)
+	}
+	goName := m.GoIdent.GoName
+	return &packet{
+		code:     code,
+		message:  m,
+		ident:    protocolImportPath.Ident(goName),
+		constant: constantName(goName),
+		name:     strings.ToUpper(goName),
+		receiver: receiverName(goName),
+	}, nil
+}
+
+//constantName turns a message name such as LoginReq into the name used
+//for its packet type constant, Loginreq
+func constantName(goName string) string {
+	return strings.ToUpper(goName[:1]) + strings.ToLower(goName[1:])
+}
+
+//receiverName builds the receiver from the first and last upper case
+//letters of the message name, LoginReq becomes lr and PeerMsgSendReq pr
+func receiverName(goName string) string {
+	first, last := goName[:1], ""
+	for _, r := range goName[1:] {
+		if r >= 'A' && r <= 'Z' {
+			last = string(r)
+		}
+	}
+	return strings.ToLower(first + last)
+}
+
+func genPackets(gen *protogen.Plugin, f *protogen.File, packets []*packet) {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".impackets.go", packetsImportPath)
+	genHeader(g, f)
+	g.P("const (")
+	for _, p := range packets {
+		g.P(p.constant, " = ", p.code)
+	}
+	g.P(")")
+	g.P()
+	g.P("func init() {")
+	for _, p := range packets {
+		g.P("mustRegisterPacketType(", p.constant, ", \"", p.name, "\", func(fh FixedHeader) ControlPacket {")
+		g.P("return &", p.constant, "Packet{FixedHeader: fh}")
+		g.P("})")
+	}
+	g.P("}")
+	for _, p := range packets {
+		r, typ, embedded := p.receiver, p.constant+"Packet", p.ident.GoName
+		g.P()
+		g.P("//", typ, " is an internal representation of the fields of the")
+		if comments := p.message.Comments.Leading; comments != "" {
+			g.P("//", p.constant, " TCP packet.")
+			g.P(strings.TrimSuffix(comments.String(), "\n"))
+		} else {
+			g.P("//", p.constant, " TCP packet")
+		}
+		g.P("type ", typ, " struct {")
+		g.P("FixedHeader")
+		g.P(p.ident)
+		g.P("}")
+		g.P()
+		g.P("func (", r, " *", typ, ") String() string {")
+		g.P("return ", fmtImportPath.Ident("Sprintf"), "(\"%s %s\", ", r, ".FixedHeader.String(), ", r, ".", embedded, ".String())")
+		g.P("}")
+		g.P()
+		g.P("func (", r, " *", typ, ") Write(w ", ioImportPath.Ident("Writer"), ") error {")
+		g.P("return writeProto(&", r, ".FixedHeader, &", r, ".", embedded, ", w)")
+		g.P("}")
+		g.P()
+		g.P("//Unpack decodes the details of a ControlPacket after the fixed")
+		g.P("//header has been read")
+		g.P("func (", r, " *", typ, ") Unpack(b ", ioImportPath.Ident("Reader"), ") error {")
+		g.P("return unpackProto(&", r, ".FixedHeader, &", r, ".", embedded, ", b)")
+		g.P("}")
+		g.P()
+		g.P("func (", r, " *", typ, ") message() ", protobufImportPath.Ident("Message"), " {")
+		g.P("return &", r, ".", embedded)
+		g.P("}")
+	}
+}
+
+func genPacketsTest(gen *protogen.Plugin, f *protogen.File, packets []*packet) {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".impackets_test.go", packetsImportPath)
+	genHeader(g, f)
+	for i, p := range packets {
+		if i > 0 {
+			g.P()
+		}
+		typ, embedded := p.constant+"Packet", p.ident.GoName
+		g.P("func Test", typ, "RoundTrip(t *", testingImportPath.Ident("T"), ") {")
+		g.P("if PacketName(", p.constant, ") != \"", p.name, "\" {")
+		g.P("t.Errorf(\"PacketName(", p.constant, ") is %s, should be %s\", PacketName(", p.constant, "), \"", p.name, "\")")
+		g.P("}")
+		g.P("for _, format := range []byte{FormatProto, FormatJson} {")
+		g.P("packet := NewControlPacket(", p.constant, ").(*", typ, ")")
+		g.P("packet.MsqSeq = ", p.code)
+		g.P("packet.Format = format")
+		g.P("b := new(", bytesImportPath.Ident("Buffer"), ")")
+		g.P("if err := packet.Write(b); err != nil {")
+		g.P("t.Fatalf(\"Write of %T returned error: %s\", packet, err)")
+		g.P("}")
+		g.P("read, err := ReadPacket(b)")
+		g.P("if err != nil {")
+		g.P("t.Fatalf(\"Read of packed %T returned error: %s\", packet, err)")
+		g.P("}")
+		g.P("readPacket, ok := read.(*", typ, ")")
+		g.P("if !ok {")
+		g.P("t.Fatalf(\"ReadPacket returned %T, should be *", typ, "\", read)")
+		g.P("}")
+		g.P("if readPacket.FixedHeader != packet.FixedHeader || !", protobufImportPath.Ident("Equal"), "(&readPacket.", embedded, ", &packet.", embedded, ") {")
+		g.P("t.Errorf(\"Read of packed %T did not equal original.\\nExpected: %v\\n     Got: %v\", packet, packet, read)")
+		g.P("}")
+		g.P("}")
+		g.P("}")
+	}
+}
+
+func genHeader(g *protogen.GeneratedFile, f *protogen.File) {
+	g.P("// Code generated by protoc-gen-impackets. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package packets")
+	g.P()
+}
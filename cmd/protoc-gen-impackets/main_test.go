@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	names := []struct {
+		goName, constant, receiver string
+	}{
+		{"LoginReq", "Loginreq", "lr"},
+		{"LoginResp", "Loginresp", "lr"},
+		{"KickoutReq", "Kickoutreq", "kr"},
+		{"PeerMsgSendReq", "Peermsgsendreq", "pr"},
+		{"Ack", "Ack", "a"},
+	}
+	for _, n := range names {
+		if res := constantName(n.goName); res != n.constant {
+			t.Errorf("constantName(%q) is %q, should be %q", n.goName, res, n.constant)
+		}
+		if res := receiverName(n.goName); res != n.receiver {
+			t.Errorf("receiverName(%q) is %q, should be %q", n.goName, res, n.receiver)
+		}
+	}
+}
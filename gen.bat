@@ -1,2 +1,4 @@
 @echo off
-protoc -I=proto --go_out=protocol proto/*.proto
\ No newline at end of file
+go install ./cmd/protoc-gen-impackets
+protoc -I=proto --go_out=protocol proto/*.proto
+protoc -I=proto --impackets_out=packets proto/*.proto
\ No newline at end of file
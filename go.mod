@@ -1,6 +1,6 @@
 module github.com/bitstreamstudio/im-packets
 
-go 1.14
+go 1.20
 
 require (
 	github.com/golang/protobuf v1.4.3
@@ -0,0 +1,61 @@
+package packets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+//FlagChecksum marks a frame followed by a checksumTrailerLength byte CRC32
+//(IEEE) trailer covering its header and payload as sent. It is applied
+//last, after compression and encryption, and RemainingLength does not
+//count the trailer.
+const FlagChecksum = 0x08
+
+//checksumTrailerLength is the encoded length of the CRC32 trailer
+const checksumTrailerLength = 4
+
+//ErrChecksumMismatch is matched by every ChecksumError
+var ErrChecksumMismatch = errors.New("frame checksum mismatch")
+
+var errChecksumMissing = errors.New("frame has no checksum")
+
+//ChecksumError is returned when the CRC32 trailer of a frame does not match
+//its header and payload, or when a frame is missing the trailer the
+//session requires. The fields are taken from the header as received, so
+//they may themselves be corrupted.
+type ChecksumError struct {
+	MessageType byte
+	MsqSeq      uint32
+	Err         error
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("tcp protocol package %s msgSeq:%d checksum mismatch: %s", PacketName(e.MessageType), e.MsqSeq, e.Err)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return e.Err
+}
+
+//Is reports ErrChecksumMismatch as matching
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+//appendChecksum appends the CRC32 trailer to frame, whose header must
+//already have FlagChecksum set
+func appendChecksum(frame []byte) []byte {
+	return appendUint32(frame, crc32.ChecksumIEEE(frame))
+}
+
+//verifyChecksum checks trailer against the header and payload of a frame
+//with header fh
+func verifyChecksum(fh FixedHeader, header, payload, trailer []byte) error {
+	sum := crc32.Update(crc32.ChecksumIEEE(header), crc32.IEEETable, payload)
+	if want := binary.BigEndian.Uint32(trailer); sum != want {
+		return &ChecksumError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: fmt.Errorf("crc32 0x%08x, trailer 0x%08x", sum, want)}
+	}
+	return nil
+}
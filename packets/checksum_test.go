@@ -0,0 +1,137 @@
+package packets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func checksumFrame(t *testing.T, cp ControlPacket) []byte {
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	e.SetChecksum(true)
+	if err := e.Encode(cp); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", cp, err)
+	}
+	return b.Bytes()
+}
+
+func TestEncoderChecksum(t *testing.T) {
+	c, _ := NewCipher(bytes.Repeat([]byte{0x42}, 32))
+	for _, packet := range shortReadPackets() {
+		for _, cipher := range []*Cipher{nil, c} {
+			b := new(bytes.Buffer)
+			e := NewEncoder(b)
+			e.SetChecksum(true)
+			e.SetCompression(FlagCompressGzip, 0)
+			e.SetCipher(cipher)
+			if err := e.Encode(packet); err != nil {
+				t.Fatalf("Encode of %T returned error: %s", packet, err)
+			}
+			frame := b.Bytes()
+			if frame[7]&FlagChecksum == 0 {
+				t.Errorf("Flag of %T is 0x%x, should have FlagChecksum set", packet, frame[7])
+			}
+
+			d := NewDecoder(b)
+			d.SetChecksum(true)
+			d.SetCipher(cipher)
+			read, err := d.Decode()
+			if err != nil {
+				t.Fatalf("Decode of %T with checksum returned error: %s", packet, err)
+			}
+			if read.String() != packet.String() {
+				t.Errorf("Decode of %T with checksum did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+			}
+			if b.Len() != 0 {
+				t.Errorf("Decode of %T left %d bytes of the frame unread", packet, b.Len())
+			}
+		}
+	}
+}
+
+func TestDecoderChecksumCorruption(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.MsqSeq = 7
+	login.UserId = "user"
+	frame := checksumFrame(t, login)
+
+	for i := range frame {
+		for _, bit := range []byte{0x01, 0x80} {
+			corrupted := append([]byte(nil), frame...)
+			corrupted[i] ^= bit
+			cp, err := NewDecoder(bytes.NewReader(corrupted)).Decode()
+			if cp != nil {
+				t.Errorf("Decode with byte %d corrupted returned %v, should return nil", i, cp)
+			}
+			if !errors.Is(err, ErrChecksumMismatch) && !errors.Is(err, ErrOutMaxPayloadLength) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Decode with byte %d corrupted returned %v, should fail on the frame", i, err)
+			}
+		}
+	}
+}
+
+func TestDecoderChecksumErrors(t *testing.T) {
+	logout := NewControlPacket(Logoutreq).(*LogoutreqPacket)
+	logout.MsqSeq = 3
+	frame := checksumFrame(t, logout)
+	corrupted := append([]byte(nil), frame...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	d := NewDecoder(bytes.NewReader(append(corrupted, frame...)))
+	for i := 0; i < 2; i++ {
+		_, err := d.Decode()
+		var checksumErr *ChecksumError
+		if !errors.As(err, &checksumErr) || !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("Decode %d after a corrupted frame returned %v, should return a ChecksumError", i, err)
+		}
+		if checksumErr.MessageType != Logoutreq || checksumErr.MsqSeq != 3 {
+			t.Errorf("ChecksumError is for %s msgSeq:%d, should be for %s msgSeq:3", PacketName(checksumErr.MessageType), checksumErr.MsqSeq, PacketName(Logoutreq))
+		}
+	}
+
+	plain := new(bytes.Buffer)
+	logout.Write(plain)
+	d = NewDecoder(plain)
+	d.SetChecksum(true)
+	if _, err := d.Decode(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Decode of a frame without checksum returned %v, should return a ChecksumError", err)
+	}
+
+	read, err := ReadPacket(bytes.NewReader(frame))
+	if err != nil {
+		t.Fatalf("ReadPacket of a frame with checksum returned error: %s", err)
+	}
+	if read.(*LogoutreqPacket).Flag != 0 {
+		t.Errorf("Flag of the read packet is 0x%x, should be cleared", read.(*LogoutreqPacket).Flag)
+	}
+}
+
+func TestSplitPacketChecksum(t *testing.T) {
+	ping := checksumFrame(t, NewControlPacket(Pingreq))
+	logout := checksumFrame(t, NewControlPacket(Logoutreq))
+	stream := append(append([]byte(nil), ping...), logout...)
+
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Split(SplitPacket)
+	for _, frame := range [][]byte{ping, logout} {
+		if !scanner.Scan() {
+			t.Fatalf("Scan returned error: %s", scanner.Err())
+		}
+		if !bytes.Equal(scanner.Bytes(), frame) {
+			t.Errorf("Frame is [0x%X], should be [0x%X]", scanner.Bytes(), frame)
+		}
+	}
+
+	stream[len(ping)+1] ^= 0xFF
+	scanner = bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Split(SplitPacket)
+	if !scanner.Scan() {
+		t.Fatalf("Scan of the first frame returned error: %s", scanner.Err())
+	}
+	if scanner.Scan() || !errors.Is(scanner.Err(), ErrChecksumMismatch) {
+		t.Errorf("Scan of a corrupted frame returned %v, should return a ChecksumError", scanner.Err())
+	}
+}
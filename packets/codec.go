@@ -0,0 +1,121 @@
+package packets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	protoV2 "google.golang.org/protobuf/proto"
+	"sync"
+)
+
+var ErrDuplicateCodec = errors.New("codec already registered")
+
+//Codec marshals the payload of proto-backed packets for one value of the
+//Format header byte
+type Codec interface {
+	//Marshal appends the encoding of m to b
+	Marshal(b []byte, m proto.Message) ([]byte, error)
+	//Unmarshal decodes b into m
+	Unmarshal(b []byte, m proto.Message) error
+}
+
+//codecs holds the Codec registered for each Format, guarded like the
+//packet type registry
+var codecs = struct {
+	sync.RWMutex
+	formats map[byte]Codec
+}{formats: make(map[byte]Codec)}
+
+func init() {
+	mustRegisterCodec(FormatProto, ProtoCodec{})
+	mustRegisterCodec(FormatJson, JsonCodec{DiscardUnknown: true})
+	mustRegisterCodec(FormatMsgpack, MsgpackCodec{})
+}
+
+//RegisterCodec makes c encode and decode the payloads of frames whose
+//Format is format. Registering a format that is already in use returns
+//ErrDuplicateCodec.
+func RegisterCodec(format byte, c Codec) error {
+	if c == nil {
+		return fmt.Errorf("nil codec for format %d", format)
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	if _, ok := codecs.formats[format]; ok {
+		return fmt.Errorf("%w: format %d", ErrDuplicateCodec, format)
+	}
+	codecs.formats[format] = c
+	return nil
+}
+
+//SetCodec installs c for format whether or not a Codec is registered for
+//it already, e.g. to change the options of a built-in codec:
+//
+//	packets.SetCodec(packets.FormatJson, packets.JsonCodec{EmitUnpopulated: true})
+func SetCodec(format byte, c Codec) {
+	if c == nil {
+		panic(fmt.Sprintf("nil codec for format %d", format))
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.formats[format] = c
+}
+
+func mustRegisterCodec(format byte, c Codec) {
+	if err := RegisterCodec(format, c); err != nil {
+		panic(err)
+	}
+}
+
+//codecFor returns the Codec registered for format. Formats without a
+//codec are handled as FormatDefault, as they always have been.
+func codecFor(format byte) Codec {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	if c, ok := codecs.formats[format]; ok {
+		return c
+	}
+	return codecs.formats[FormatDefault]
+}
+
+//hasCodec reports whether a Codec is registered for format
+func hasCodec(format byte) bool {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	_, ok := codecs.formats[format]
+	return ok
+}
+
+//ProtoCodec is the Codec of FormatProto, the protobuf wire format
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(b []byte, m proto.Message) ([]byte, error) {
+	return protoV2.MarshalOptions{}.MarshalAppend(b, proto.MessageV2(m))
+}
+
+func (ProtoCodec) Unmarshal(b []byte, m proto.Message) error {
+	return proto.Unmarshal(b, m)
+}
+
+//JsonCodec is the Codec of FormatJson. It follows the canonical proto3
+//JSON mapping, the one protobuf.js and the other official runtimes use:
+//lowerCamelCase field names, enums by name and 64 bit integers as strings.
+//Both lowerCamelCase and the original field names are accepted when
+//decoding. The codec registered by default discards unknown fields.
+type JsonCodec struct {
+	//EmitUnpopulated writes fields holding their default value
+	EmitUnpopulated bool
+	//DiscardUnknown ignores fields unknown to the message instead of
+	//failing
+	DiscardUnknown bool
+}
+
+func (c JsonCodec) Marshal(b []byte, m proto.Message) ([]byte, error) {
+	bytes, err := protojson.MarshalOptions{EmitUnpopulated: c.EmitUnpopulated}.Marshal(proto.MessageV2(m))
+	return append(b, bytes...), err
+}
+
+func (c JsonCodec) Unmarshal(b []byte, m proto.Message) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: c.DiscardUnknown}.Unmarshal(b, proto.MessageV2(m))
+}
@@ -0,0 +1,72 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"github.com/golang/protobuf/proto"
+	"io"
+	"testing"
+)
+
+const testCodecFormat = 0x7F
+
+//reverseCodec is the protobuf wire format stored back to front
+type reverseCodec struct{}
+
+func (reverseCodec) Marshal(b []byte, m proto.Message) ([]byte, error) {
+	payload, err := proto.Marshal(m)
+	for i := len(payload) - 1; i >= 0; i-- {
+		b = append(b, payload[i])
+	}
+	return b, err
+}
+
+func (reverseCodec) Unmarshal(b []byte, m proto.Message) error {
+	payload := make([]byte, len(b))
+	for i := range b {
+		payload[len(b)-1-i] = b[i]
+	}
+	return proto.Unmarshal(payload, m)
+}
+
+func init() {
+	mustRegisterCodec(testCodecFormat, reverseCodec{})
+}
+
+func TestRegisterCodecDuplicate(t *testing.T) {
+	if err := RegisterCodec(FormatJson, reverseCodec{}); !errors.Is(err, ErrDuplicateCodec) {
+		t.Errorf("RegisterCodec(FormatJson) returned %v, should be %v", err, ErrDuplicateCodec)
+	}
+}
+
+func TestCodecFormats(t *testing.T) {
+	for _, format := range []byte{FormatProto, FormatJson, FormatMsgpack, testCodecFormat, 0x7E} {
+		login := NewControlPacket(Loginreq).(*LoginreqPacket)
+		login.Format = format
+		login.UserId = "user"
+		login.Token = "token"
+		for _, encode := range []func(io.Writer) error{
+			login.Write,
+			func(w io.Writer) error { return NewEncoder(w).Encode(login) },
+		} {
+			b := new(bytes.Buffer)
+			if err := encode(b); err != nil {
+				t.Fatalf("Format %d: Write of %T returned error: %s", format, login, err)
+			}
+			read, err := ReadPacket(b)
+			if err != nil {
+				t.Fatalf("Format %d: Read of packed %T returned error: %s", format, login, err)
+			}
+			loginreqPacket := read.(*LoginreqPacket)
+			if loginreqPacket.Format != format || !proto.Equal(&loginreqPacket.LoginReq, &login.LoginReq) {
+				t.Errorf("Format %d: Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", format, login, login, read)
+			}
+		}
+	}
+
+	expected, _ := ProtoCodec{}.Marshal(nil, &protocol.LoginReq{UserId: "user"})
+	if res, _ := codecFor(0x7E).Marshal(nil, &protocol.LoginReq{UserId: "user"}); !bytes.Equal(res, expected) {
+		t.Errorf("Unregistered format encoded [0x%X], should fall back to FormatDefault [0x%X]", res, expected)
+	}
+}
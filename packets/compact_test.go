@@ -0,0 +1,159 @@
+package packets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncoderCompact(t *testing.T) {
+	c, _ := NewCipher(bytes.Repeat([]byte{0x42}, 32))
+	for _, cipher := range []*Cipher{nil, c} {
+		for _, checksum := range []bool{false, true} {
+			b := new(bytes.Buffer)
+			e := NewEncoder(b)
+			e.SetCompactHeader(true)
+			e.SetCipher(cipher)
+			e.SetChecksum(checksum)
+			written := shortReadPackets()
+			for _, packet := range written {
+				if err := e.Encode(packet); err != nil {
+					t.Fatalf("Encode of %T returned error: %s", packet, err)
+				}
+			}
+
+			d := NewDecoder(b)
+			d.SetVersionRange(CurrentVersion, CurrentVersion)
+			d.SetCipher(cipher)
+			d.SetChecksum(checksum)
+			for _, packet := range written {
+				read, err := d.Decode()
+				if err != nil {
+					t.Fatalf("Decode of compact %T returned error: %s", packet, err)
+				}
+				if read.String() != packet.String() {
+					t.Errorf("Decode of compact %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+				}
+			}
+			if b.Len() != 0 {
+				t.Errorf("Decode of compact frames left %d bytes unread", b.Len())
+			}
+		}
+	}
+}
+
+func TestCompactHeaderLength(t *testing.T) {
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	e.SetCompactHeader(true)
+	ping := NewControlPacket(Pingreq).(*PingreqPacket)
+	ping.MsqSeq = 100
+	if err := e.Encode(ping); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", ping, err)
+	}
+	expected := []byte{compactHeaderMarker, Pingreq, 0, CurrentVersion, 100, 0}
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("Compact PingreqPacket is [0x%X], should be [0x%X]", b.Bytes(), expected)
+	}
+
+	unfit := &RawPacket{FixedHeader: FixedHeader{MessageType: Logoutreq, Flag: 0x80}}
+	b.Reset()
+	if err := e.Encode(unfit); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", unfit, err)
+	}
+	if b.Len() != fixedHeaderLength {
+		t.Errorf("Frame with Flag 0x80 is [0x%X], should keep the full header", b.Bytes())
+	}
+}
+
+func TestCompactHeaderVersion(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetVersion(1)
+		e.SetCompactHeader(compact)
+		ping := NewControlPacket(Pingreq).(*PingreqPacket)
+		ping.MsqSeq = 100
+		if err := e.Encode(ping); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", ping, err)
+		}
+		if isCompact := b.Bytes()[0] == compactHeaderMarker; isCompact != compact {
+			t.Errorf("Frame [0x%X] has compact header %t, should be %t", b.Bytes(), isCompact, compact)
+		}
+		read, err := ReadPacket(b)
+		if err != nil {
+			t.Fatalf("ReadPacket of version 1 frame returned error: %s", err)
+		}
+		if fh := read.(*PingreqPacket).FixedHeader; fh.Version != 1 || fh.MsqSeq != 100 {
+			t.Errorf("ReadPacket of version 1 frame returned %v", fh)
+		}
+	}
+}
+
+func TestCompactMixedStream(t *testing.T) {
+	written := shortReadPackets()
+	stream := new(bytes.Buffer)
+	compact := NewEncoder(stream)
+	compact.SetCompactHeader(true)
+	full := NewEncoder(stream)
+	for i, packet := range written {
+		e := compact
+		if i%2 == 1 {
+			e = full
+		}
+		if err := e.Encode(packet); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", packet, err)
+		}
+	}
+	frames := stream.Bytes()
+
+	for i, packet := range written {
+		read, err := ReadPacket(stream)
+		if err != nil {
+			t.Fatalf("ReadPacket of frame %d returned error: %s", i, err)
+		}
+		if read.String() != packet.String() {
+			t.Errorf("ReadPacket of frame %d did not equal original.\nExpected: %v\n     Got: %v", i, packet, read)
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(frames))
+	scanner.Split(SplitPacket)
+	var n int
+	for ; scanner.Scan(); n++ {
+		compactFrame := scanner.Bytes()[0] == compactHeaderMarker
+		if compactFrame != (n%2 == 0) {
+			t.Errorf("Frame %d has compact header %t, should be %t", n, compactFrame, n%2 == 0)
+		}
+	}
+	if scanner.Err() != nil || n != len(written) {
+		t.Errorf("SplitPacket returned %d frames and error %v, should return %d frames", n, scanner.Err(), len(written))
+	}
+}
+
+func TestCompactHeaderErrors(t *testing.T) {
+	malformed := []byte{compactHeaderMarker, Pingreq, 0, CurrentVersion, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 0}
+	if _, err := ReadPacket(bytes.NewReader(malformed)); !errors.Is(err, errCompactVarint) {
+		t.Errorf("ReadPacket of an overlong varint returned %v, should return %v", err, errCompactVarint)
+	}
+	overflow := []byte{compactHeaderMarker, Pingreq, 0, CurrentVersion, 0xFF, 0xFF, 0xFF, 0xFF, 0x1F, 0}
+	if _, err := ReadPacket(bytes.NewReader(overflow)); !errors.Is(err, errCompactVarint) {
+		t.Errorf("ReadPacket of a varint above MaxUint32 returned %v, should return %v", err, errCompactVarint)
+	}
+
+	ping := new(bytes.Buffer)
+	NewControlPacket(Pingreq).Write(ping)
+	d := NewDecoder(io.MultiReader(bytes.NewReader(malformed[:10]), ping))
+	for i := 0; i < 2; i++ {
+		if cp, err := d.Decode(); !errors.Is(err, errCompactVarint) {
+			t.Errorf("Decode %d after a malformed compact header returned %v, %v, should return %v", i, cp, err, errCompactVarint)
+		}
+	}
+	if err := RegisterPacketType(compactHeaderMarker, "MARKER", func(fh FixedHeader) ControlPacket {
+		return &PingreqPacket{FixedHeader: fh}
+	}); err == nil {
+		t.Errorf("RegisterPacketType(0x%x) returned no error", compactHeaderMarker)
+	}
+}
@@ -0,0 +1,109 @@
+package packets
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+)
+
+//Below are the bits of FixedHeader.Flag selecting the algorithm the
+//payload is compressed with, a frame with none of them set is not
+//compressed
+const (
+	FlagCompressGzip    = 0x01
+	FlagCompressDeflate = 0x02
+	FlagCompressZlib    = 0x03
+	FlagCompressionMask = 0x03
+)
+
+//compressor is implemented by the writers of compress/gzip, compress/flate
+//and compress/zlib
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+//compressors pools the writers of each algorithm, they are expensive to
+//allocate
+var compressors = map[byte]*sync.Pool{
+	FlagCompressGzip: {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+	FlagCompressDeflate: {New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	}},
+	FlagCompressZlib: {New: func() interface{} {
+		return zlib.NewWriter(nil)
+	}},
+}
+
+//compressFrame appends frame to dst with its payload compressed by the
+//algorithm selected by flag, setting the flag and RemainingLength in the
+//header. Frames the algorithm does not make any smaller are appended as is.
+func compressFrame(dst, frame []byte, flag byte) ([]byte, error) {
+	var fh FixedHeader
+	fh.unpack(frame)
+	payload := frame[fixedHeaderLength:]
+	start := len(dst)
+	w := appendWriter(fh.appendTo(dst))
+
+	pool := compressors[flag]
+	zw := pool.Get().(compressor)
+	zw.Reset(&w)
+	_, err := zw.Write(payload)
+	if err == nil {
+		err = zw.Close()
+	}
+	zw.Reset(nil)
+	pool.Put(zw)
+	if err != nil {
+		return dst, err
+	}
+
+	compressed := len(w) - start - fixedHeaderLength
+	if compressed >= len(payload) {
+		return append(dst[:start], frame...), nil
+	}
+	fh.Flag |= flag
+	fh.RemainingLength = uint32(compressed)
+	fh.appendTo(w[start:start])
+	return w, nil
+}
+
+//decompress decompresses payload, compressed by the algorithm selected by
+//flag, into out. At most limit bytes are decompressed, a payload
+//inflating beyond that returns a PayloadLengthError.
+func decompress(out *bytes.Buffer, payload []byte, fh FixedHeader, limit uint32) error {
+	var zr io.Reader
+	var err error
+	switch fh.Flag & FlagCompressionMask {
+	case FlagCompressGzip:
+		zr, err = gzip.NewReader(bytes.NewReader(payload))
+	case FlagCompressDeflate:
+		zr = flate.NewReader(bytes.NewReader(payload))
+	case FlagCompressZlib:
+		zr, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return fmt.Errorf("unsupported compression flag 0x%x", fh.Flag)
+	}
+	if err != nil {
+		return err
+	}
+	out.Reset()
+	n, err := out.ReadFrom(io.LimitReader(zr, int64(limit)+1))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if n > int64(limit) {
+		return &PayloadLengthError{MessageType: fh.MessageType, Length: uint32(n), Limit: limit}
+	}
+	return nil
+}
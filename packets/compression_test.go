@@ -0,0 +1,98 @@
+package packets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"strings"
+	"testing"
+)
+
+func TestEncoderCompression(t *testing.T) {
+	text := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+	text.MsqSeq = 1
+	text.Body = &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Content: strings.Repeat("compressible ", 100)}}
+	for _, flag := range []byte{FlagCompressGzip, FlagCompressDeflate, FlagCompressZlib} {
+		for _, format := range []byte{FormatProto, FormatJson} {
+			text.Format = format
+			b := new(bytes.Buffer)
+			e := NewEncoder(b)
+			e.SetCompression(flag, 256)
+			if err := e.Encode(text); err != nil {
+				t.Fatalf("Encode of %T returned error: %s", text, err)
+			}
+			frame := b.Bytes()
+			if frame[7]&FlagCompressionMask != flag {
+				t.Errorf("Flag of the compressed frame is 0x%x, should be 0x%x", frame[7], flag)
+			}
+			if len(frame)-fixedHeaderLength >= int(text.RemainingLength) {
+				t.Errorf("Compressed payload is %d bytes, should be smaller than %d", len(frame)-fixedHeaderLength, text.RemainingLength)
+			}
+			read, err := ReadPacket(b)
+			if err != nil {
+				t.Fatalf("Read of compressed %T returned error: %s", text, err)
+			}
+			if read.String() != text.String() {
+				t.Errorf("Read of compressed %T did not equal original.\nExpected: %v\n     Got: %v", text, text, read)
+			}
+		}
+	}
+}
+
+func TestEncoderCompressionSkipped(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	packets := map[string]ControlPacket{
+		"below threshold": login,
+		"payload-less":    NewControlPacket(Pingreq),
+		"incompressible":  &RawPacket{FixedHeader: FixedHeader{MessageType: 0xC0}, Payload: []byte("\x8f\x12\x07\xfe\x00\x33\x9a\x61")},
+	}
+	for name, packet := range packets {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetCompression(FlagCompressGzip, 0)
+		if name == "below threshold" {
+			e.SetCompression(FlagCompressGzip, 1024)
+		}
+		if err := e.Encode(packet); err != nil {
+			t.Fatalf("%s: Encode of %T returned error: %s", name, packet, err)
+		}
+		expected := new(bytes.Buffer)
+		packet.Write(expected)
+		if !bytes.Equal(b.Bytes(), expected.Bytes()) {
+			t.Errorf("%s: Encode of %T returned [0x%X], should not compress it to [0x%X]", name, packet, expected.Bytes(), b.Bytes())
+		}
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	var payload bytes.Buffer
+	zw := gzip.NewWriter(&payload)
+	zw.Write(make([]byte, MAX_PAYLOAD_LENGTH_3MB+1))
+	zw.Close()
+	fh := FixedHeader{MessageType: Loginreq, Flag: FlagCompressGzip, RemainingLength: uint32(payload.Len())}
+	frame := fh.pack()
+	frame.Write(payload.Bytes())
+
+	_, err := ReadPacket(bytes.NewReader(frame.Bytes()))
+	var lengthErr *PayloadLengthError
+	if !errors.As(err, &lengthErr) || lengthErr.Limit != MAX_PAYLOAD_LENGTH_3MB {
+		t.Errorf("ReadPacket of a decompression bomb returned %v, should be a *PayloadLengthError", err)
+	}
+
+	d := NewDecoder(bytes.NewReader(frame.Bytes()))
+	d.SetMaxPayloadLength(1024)
+	if _, err := d.Decode(); !errors.Is(err, ErrOutMaxPayloadLength) {
+		t.Errorf("Decode of a decompression bomb returned %v, should be %v", err, ErrOutMaxPayloadLength)
+	}
+}
+
+func TestDecompressionCorrupt(t *testing.T) {
+	fh := FixedHeader{MessageType: Loginreq, Flag: FlagCompressDeflate, RemainingLength: 4}
+	frame := fh.pack()
+	frame.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := ReadPacket(&frame); err == nil {
+		t.Errorf("ReadPacket of a corrupt compressed payload did not return an error")
+	}
+}
@@ -0,0 +1,343 @@
+package packets
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+//Decoder reads ControlPackets from an io.Reader. Unlike ReadPacket it keeps
+//its header and payload buffers between calls, so decoding a stream of
+//packets does not allocate per frame beyond the packet itself.
+//A Decoder is not safe for concurrent use.
+type Decoder struct {
+	r                io.Reader
+	header           [maxCompactHeaderLength]byte
+	headerLength     int
+	frame            FixedHeader
+	trailer          [checksumTrailerLength]byte
+	payload          []byte
+	reader           bytes.Reader
+	inflated         bytes.Buffer
+	plaintext        []byte
+	cipher           *Cipher
+	maxPayload       uint32
+	packetMaxPayload map[byte]uint32
+	rawUnknown       bool
+	checksum         bool
+	strict           bool
+	fragments        *reassembler
+	minVersion       byte
+	maxVersion       byte
+	err              error
+}
+
+//NewDecoder returns a Decoder reading from r. The Decoder only reads the
+//bytes of the frames it decodes, so r does not need to be buffered for
+//correctness, wrapping a net.Conn in a bufio.Reader does reduce syscalls.
+//Payloads are limited to MAX_PAYLOAD_LENGTH_3MB until changed with
+//SetMaxPayloadLength, frames of any Version are accepted until changed
+//with SetVersionRange.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, maxPayload: MAX_PAYLOAD_LENGTH_3MB, maxVersion: 0xFF}
+}
+
+//SetMaxPayloadLength sets the largest RemainingLength accepted for packet
+//types without a limit of their own. It may be changed between calls to
+//Decode, e.g. raised once the connection has logged in.
+func (d *Decoder) SetMaxPayloadLength(n uint32) {
+	d.maxPayload = n
+}
+
+//SetPacketMaxPayloadLength sets the largest RemainingLength accepted for
+//packets of packetType, overriding the limit set by SetMaxPayloadLength in
+//either direction.
+func (d *Decoder) SetPacketMaxPayloadLength(packetType byte, n uint32) {
+	if d.packetMaxPayload == nil {
+		d.packetMaxPayload = make(map[byte]uint32)
+	}
+	d.packetMaxPayload[packetType] = n
+}
+
+//SetRawUnknown makes Decode return packets of unregistered types as a
+//*RawPacket instead of failing, so that a connection survives packets
+//introduced by newer peers.
+func (d *Decoder) SetRawUnknown(enabled bool) {
+	d.rawUnknown = enabled
+}
+
+//SetCipher makes the Decoder decrypt payloads with c, as established by a
+//KeyExchange. From then on frames with a payload must be encrypted, any
+//other frame fails to decode with a DecryptError. A nil c turns decryption
+//off again.
+func (d *Decoder) SetCipher(c *Cipher) {
+	d.cipher = c
+}
+
+//SetChecksum makes the Decoder require a CRC32 trailer on every frame,
+//frames without one fail to decode with a ChecksumError. Frames carrying
+//a trailer are verified either way.
+func (d *Decoder) SetChecksum(required bool) {
+	d.checksum = required
+}
+
+//SetReassembly makes the Decoder reassemble packets sent as fragments, see
+//Fragment. Decode keeps returning the packets read in between and returns
+//a fragmented packet once its last fragment has been read. The packets
+//being reassembled may announce at most maxLength bytes together, a packet
+//whose fragments span more than timeout fails with a FragmentError
+//wrapping ErrFragmentTimeout. Without reassembly, fragments fail to decode
+//with a FragmentError.
+func (d *Decoder) SetReassembly(maxLength uint32, timeout time.Duration) {
+	d.fragments = newReassembler(maxLength, timeout)
+}
+
+//SetVersionRange makes the Decoder accept only frames whose Version lies
+//between min and max inclusive. A frame of any other version fails to
+//decode with a VersionError, as does every later call, since the layout
+//of the rest of the frame is unknown.
+func (d *Decoder) SetVersionRange(min, max byte) {
+	d.minVersion = min
+	d.maxVersion = max
+}
+
+//SetStrict makes the Decoder reject frames the default mode lets through:
+//a Format without a registered Codec, reserved Flag bits, a payload left
+//partly or wholly unread by Unpack, as happens to a payload sent with a
+//PingreqPacket, PingrespPacket or DisconnectPacket, and proto payloads
+//holding unknown fields, i.e. trailing data decoded as fields. Such frames
+//fail with a ProtocolError coded ErrProtocolViolation that wraps
+//ErrUnknownFormat, ErrReservedFlag or ErrTrailingData. The frame is read
+//in full first, so the next call decodes the following frame.
+func (d *Decoder) SetStrict(enabled bool) {
+	d.strict = enabled
+}
+
+//maxPayloadLength returns the limit applying to packets of packetType
+func (d *Decoder) maxPayloadLength(packetType byte) uint32 {
+	if n, ok := d.packetMaxPayload[packetType]; ok {
+		return n
+	}
+	return d.maxPayload
+}
+
+//Decode reads the next packet from the underlying reader. It returns a
+//ControlPacket representing the decoded packet and an error. One of these
+//returns will always be nil, a nil ControlPacket indicating an error
+//occurred. io.EOF is only returned when the stream ends between frames,
+//any other error is a *ProtocolError, its Code being ErrNetworkError when
+//reading failed, e.g. with io.ErrUnexpectedEOF for a frame cut short.
+//Encrypted payloads are decrypted and compressed payloads decompressed,
+//the header of the returned packet then describes the resulting payload,
+//to which the payload length limits apply as well. Fragments are
+//reassembled as set up with SetReassembly.
+//
+//A ChecksumError, a VersionError, a malformed compact header or a
+//PayloadLengthError for a frame announcing a RemainingLength above the
+//limit leaves the position of the next frame in the stream unknown, the
+//Decoder does not try to resynchronize on it or read past an oversized
+//payload. Every later call returns the same error and the connection
+//should be closed.
+//
+//Packets must not keep references to the bytes passed to Unpack, as the
+//buffer is reused by the next call.
+func (d *Decoder) Decode() (ControlPacket, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	cp, err := d.decode()
+	if err == nil || err == io.EOF {
+		return cp, err
+	}
+	pe := protocolError(d.frame, err)
+	if d.err != nil {
+		d.err = pe
+	}
+	return nil, pe
+}
+
+func (d *Decoder) decode() (ControlPacket, error) {
+	for {
+		d.frame = FixedHeader{}
+		if d.fragments != nil {
+			if err := d.fragments.expire(); err != nil {
+				return nil, err
+			}
+		}
+		fh, payload, err := d.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if fh.Flag&FlagFragment != 0 {
+			if d.fragments == nil {
+				return nil, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errReassemblyDisabled}
+			}
+			var complete bool
+			fh, payload, complete, err = d.fragments.add(fh, payload)
+			if err != nil {
+				return nil, err
+			}
+			if !complete {
+				continue
+			}
+		}
+		return d.unpack(fh, payload)
+	}
+}
+
+//readFrame reads the next frame, returning its header and payload with
+//the checksum verified, the payload decrypted and decompressed
+func (d *Decoder) readFrame() (FixedHeader, []byte, error) {
+	fh, err := d.readHeader()
+	if err != nil {
+		return fh, nil, err
+	}
+	d.frame = fh
+	if fh.Version < d.minVersion || fh.Version > d.maxVersion {
+		d.err = &VersionError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Version: fh.Version, Min: d.minVersion, Max: d.maxVersion}
+		return fh, nil, d.err
+	}
+	if d.checksum && fh.Flag&FlagChecksum == 0 {
+		d.err = &ChecksumError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errChecksumMissing}
+		return fh, nil, d.err
+	}
+	if limit := d.maxPayloadLength(fh.MessageType); fh.RemainingLength > limit {
+		d.err = &PayloadLengthError{MessageType: fh.MessageType, Length: fh.RemainingLength, Limit: limit}
+		return fh, nil, d.err
+	}
+
+	if err := d.readPayload(int(fh.RemainingLength)); err != nil {
+		return fh, nil, readError(err)
+	}
+	if fh.Flag&FlagChecksum != 0 {
+		if err := readFull(d.r, d.trailer[:]); err != nil {
+			return fh, nil, readError(err)
+		}
+		if err := verifyChecksum(fh, d.header[:d.headerLength], d.payload, d.trailer[:]); err != nil {
+			d.err = err
+			return fh, nil, err
+		}
+		fh.Flag &^= FlagChecksum
+	}
+	if d.strict {
+		if err := checkStrictHeader(fh); err != nil {
+			return fh, nil, err
+		}
+	}
+	payload := d.payload
+	if fh.Flag&FlagEncrypted != 0 {
+		if d.cipher == nil {
+			return fh, nil, &DecryptError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errNoCipher}
+		}
+		plaintext, err := d.cipher.open(d.plaintext[:0], fh, payload)
+		if err != nil {
+			return fh, nil, err
+		}
+		d.plaintext = plaintext
+		payload = plaintext
+		fh.Flag &^= FlagEncrypted
+		fh.RemainingLength = uint32(len(payload))
+	} else if d.cipher != nil && len(payload) > 0 {
+		return fh, nil, &DecryptError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errNotEncrypted}
+	}
+	if fh.Flag&FlagCompressionMask != 0 {
+		if err := decompress(&d.inflated, payload, fh, d.maxPayloadLength(fh.MessageType)); err != nil {
+			return fh, nil, err
+		}
+		fh.Flag &^= FlagCompressionMask
+		fh.RemainingLength = uint32(d.inflated.Len())
+		payload = d.inflated.Bytes()
+	}
+	return fh, payload, nil
+}
+
+//readHeader reads the header of the next frame in either layout into
+//d.header, reading no further than its end. A malformed compact header
+//leaves the end of the frame unknown and fails every later call.
+func (d *Decoder) readHeader() (FixedHeader, error) {
+	if _, err := io.ReadFull(d.r, d.header[:1]); err != nil {
+		return FixedHeader{}, readError(err)
+	}
+	n := 1
+	for {
+		fh, length, err := unpackHeader(d.header[:n])
+		if err != nil {
+			d.err = err
+			return fh, err
+		}
+		if length > 0 {
+			d.headerLength = length
+			return fh, nil
+		}
+		next := n + 1
+		if d.header[0] != compactHeaderMarker {
+			next = fixedHeaderLength
+		} else if n < 4 {
+			next = 4
+		}
+		if err := readFull(d.r, d.header[n:next]); err != nil {
+			return fh, readError(err)
+		}
+		n = next
+	}
+}
+
+//unpack decodes payload into a new packet of the type selected by fh,
+//upgrading it first if it was written in another version
+func (d *Decoder) unpack(fh FixedHeader, payload []byte) (ControlPacket, error) {
+	fh, payload, err := upgradeVersion(fh, payload)
+	if err != nil {
+		return nil, err
+	}
+	d.reader.Reset(payload)
+	cp, err := NewControlPacketWithHeader(fh)
+	if err != nil {
+		if !d.rawUnknown {
+			return nil, err
+		}
+		cp = &RawPacket{FixedHeader: fh}
+	}
+
+	if err := cp.Unpack(&d.reader); err != nil {
+		return nil, err
+	}
+	if d.strict {
+		if err := checkStrictPayload(cp, d.reader.Len()); err != nil {
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+//payloadChunkSize is how much of a payload is buffered ahead of the bytes
+//actually received, a peer announcing a large RemainingLength has to send
+//the data before the Decoder allocates room for all of it
+const payloadChunkSize = 64 * 1024
+
+//readPayload reads n bytes into d.payload, growing it as the data arrives
+func (d *Decoder) readPayload(n int) error {
+	d.payload = d.payload[:0]
+	for len(d.payload) < n {
+		if len(d.payload) == cap(d.payload) {
+			size := 2 * cap(d.payload)
+			if size < payloadChunkSize {
+				size = payloadChunkSize
+			}
+			if size > n {
+				size = n
+			}
+			payload := make([]byte, len(d.payload), size)
+			copy(payload, d.payload)
+			d.payload = payload
+		}
+		end := cap(d.payload)
+		if end > n {
+			end = n
+		}
+		if err := readFull(d.r, d.payload[len(d.payload):end]); err != nil {
+			return err
+		}
+		d.payload = d.payload[:end]
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	written := []ControlPacket{
+		NewControlPacket(Pingreq),
+		login,
+		NewControlPacket(Pingresp),
+		NewControlPacket(Logoutreq),
+		NewControlPacket(Disconnect),
+	}
+	for _, packet := range written {
+		if err := packet.Write(buf); err != nil {
+			t.Fatalf("Write of %T returned error: %s", packet, err)
+		}
+	}
+	d := NewDecoder(buf)
+	for _, packet := range written {
+		read, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode of packed %T returned error: %s", packet, err)
+		}
+		if read.String() != packet.String() {
+			t.Errorf("Decode of packed %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("Decode at end of stream returned %v, should be %v", err, io.EOF)
+	}
+}
+
+func TestDecoderAllocs(t *testing.T) {
+	frame := new(bytes.Buffer)
+	NewControlPacket(Pingreq).Write(frame)
+	r := bytes.NewReader(frame.Bytes())
+	d := NewDecoder(r)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Seek(0, io.SeekStart)
+		if _, err := d.Decode(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("Decode of a PINGREQ allocated %.0f times, should allocate at most the packet", allocs)
+	}
+}
+
+func benchmarkDecode(b *testing.B, packet ControlPacket) {
+	frame := new(bytes.Buffer)
+	if err := packet.Write(frame); err != nil {
+		b.Fatal(err)
+	}
+	r := bytes.NewReader(frame.Bytes())
+	d := NewDecoder(r)
+	b.ReportAllocs()
+	b.SetBytes(int64(frame.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, io.SeekStart)
+		if _, err := d.Decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodePingreq(b *testing.B) {
+	benchmarkDecode(b, NewControlPacket(Pingreq))
+}
+
+func BenchmarkDecodeLoginreq(b *testing.B) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	benchmarkDecode(b, login)
+}
+
+func BenchmarkReadPacketPingreq(b *testing.B) {
+	frame := new(bytes.Buffer)
+	NewControlPacket(Pingreq).Write(frame)
+	r := bytes.NewReader(frame.Bytes())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, io.SeekStart)
+		if _, err := ReadPacket(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecoderMaxPayloadLength(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "a rather long user id"
+	login.Token = "and an even longer token"
+	frame := new(bytes.Buffer)
+	login.Write(frame)
+
+	d := NewDecoder(bytes.NewReader(frame.Bytes()))
+	d.SetMaxPayloadLength(16)
+	_, err := d.Decode()
+	var lengthErr *PayloadLengthError
+	if !errors.As(err, &lengthErr) {
+		t.Fatalf("Decode over the limit returned %v, should be a *PayloadLengthError", err)
+	}
+	if lengthErr.MessageType != Loginreq || lengthErr.Length != login.RemainingLength || lengthErr.Limit != 16 {
+		t.Errorf("PayloadLengthError is %+v, should report LOGINREQ, %d and 16", lengthErr, login.RemainingLength)
+	}
+	if !errors.Is(err, ErrOutMaxPayloadLength) {
+		t.Errorf("errors.Is(%v, ErrOutMaxPayloadLength) is false", err)
+	}
+
+	ping := new(bytes.Buffer)
+	NewControlPacket(Pingreq).Write(ping)
+	d = NewDecoder(io.MultiReader(bytes.NewReader(frame.Bytes()), ping))
+	d.SetMaxPayloadLength(16)
+	for i := 0; i < 2; i++ {
+		if cp, err := d.Decode(); !errors.As(err, &lengthErr) {
+			t.Errorf("Decode %d after a frame over the limit returned %v, %v, should return the PayloadLengthError", i, cp, err)
+		}
+	}
+
+	d = NewDecoder(bytes.NewReader(frame.Bytes()))
+	d.SetMaxPayloadLength(16)
+	d.SetPacketMaxPayloadLength(Loginreq, 1024)
+	if _, err := d.Decode(); err != nil {
+		t.Errorf("Decode under the packet limit returned error: %s", err)
+	}
+
+	d = NewDecoder(bytes.NewReader(frame.Bytes()))
+	d.SetPacketMaxPayloadLength(Loginreq, 0)
+	if _, err := d.Decode(); !errors.Is(err, ErrOutMaxPayloadLength) {
+		t.Errorf("Decode over the packet limit returned %v, should be %v", err, ErrOutMaxPayloadLength)
+	}
+}
+
+func TestReadPacketMaxPayloadLength(t *testing.T) {
+	header := FixedHeader{MessageType: Loginreq, RemainingLength: MAX_PAYLOAD_LENGTH_3MB + 1}
+	frame := header.pack()
+	if _, err := ReadPacket(&frame); !errors.Is(err, ErrOutMaxPayloadLength) {
+		t.Errorf("ReadPacket over the limit returned %v, should be %v", err, ErrOutMaxPayloadLength)
+	}
+}
+
+func TestDecoderAnnouncedLengthAllocation(t *testing.T) {
+	header := FixedHeader{MessageType: Loginreq, RemainingLength: MAX_PAYLOAD_LENGTH_3MB}
+	frame := header.pack()
+	frame.WriteString("short")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := ReadPacket(&frame); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadPacket of a truncated frame returned %v, should be %v", err, io.ErrUnexpectedEOF)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 2*payloadChunkSize {
+		t.Errorf("ReadPacket allocated %d bytes for a 17 byte frame", allocated)
+	}
+}
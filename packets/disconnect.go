@@ -5,7 +5,8 @@ import (
 )
 
 //DisconnectPacket is an internal representation of the fields of the
-//Disconnect MQTT packet
+//Disconnect MQTT packet. It only closes the transport, the login session
+//stays valid, use LogoutreqPacket to end the session itself.
 type DisconnectPacket struct {
 	FixedHeader
 }
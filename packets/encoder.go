@@ -0,0 +1,198 @@
+package packets
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+//maxPooledBufferSize keeps the occasional large frame from pinning its
+//buffer in the pool
+const maxPooledBufferSize = 64 * 1024
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+//Encoder writes ControlPackets to an io.Writer. Each packet is framed into
+//a pooled buffer, header and payload together, and handed to the writer
+//in exactly one Write call, so on a net.Conn a frame is never split into
+//a header segment and a payload segment.
+//An Encoder is not safe for concurrent use.
+type Encoder struct {
+	w                    io.Writer
+	compression          byte
+	compressionThreshold int
+	cipher               *Cipher
+	checksum             bool
+	fragmentSize         int
+	version              byte
+	setVersion           bool
+	compact              bool
+}
+
+//NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+//SetCompression makes the Encoder compress payloads of at least threshold
+//bytes with the algorithm selected by flag, one of FlagCompressGzip,
+//FlagCompressDeflate or FlagCompressZlib. A flag of 0 turns compression
+//off. ReadPacket and Decoder decompress such frames transparently.
+func (e *Encoder) SetCompression(flag byte, threshold int) {
+	if flag&^FlagCompressionMask != 0 {
+		panic(fmt.Sprintf("invalid compression flag 0x%x", flag))
+	}
+	e.compression = flag
+	e.compressionThreshold = threshold
+}
+
+//SetCipher makes the Encoder encrypt every payload with c, as established
+//by a KeyExchange. Payloads are compressed before they are encrypted.
+//A nil c turns encryption off again.
+func (e *Encoder) SetCipher(c *Cipher) {
+	e.cipher = c
+}
+
+//SetChecksum makes the Encoder follow every frame with a CRC32 trailer,
+//letting the peer detect frames corrupted on the way instead of
+//misparsing them.
+func (e *Encoder) SetChecksum(enabled bool) {
+	e.checksum = enabled
+}
+
+//SetFragmentSize makes the Encoder send packets whose payload exceeds size
+//bytes as fragments of at most size bytes, each written on its own. A
+//size of 0 turns fragmentation off. The peer needs a Decoder with
+//SetReassembly enabled to read them.
+func (e *Encoder) SetFragmentSize(size int) {
+	if size < 0 {
+		panic(fmt.Sprintf("invalid fragment size %d", size))
+	}
+	e.fragmentSize = size
+}
+
+//SetVersion makes the Encoder write every frame with version, as picked by
+//NegotiateVersion, in place of the Version of the packet's header.
+func (e *Encoder) SetVersion(version byte) {
+	e.version = version
+	e.setVersion = true
+}
+
+//SetCompactHeader makes the Encoder write frames with the compact header,
+//which packs Format and Flag into one byte and sends MsqSeq and
+//RemainingLength as varints, so a PingreqPacket takes 6 bytes instead of
+//12. Frames whose Format or Flag do not fit it keep the full header.
+//ReadPacket and Decoder read either layout, the Version is sent in both.
+//The compact header is marked by a leading 0 byte, not by a Version, so
+//packet type 0 is reserved for it.
+func (e *Encoder) SetCompactHeader(enabled bool) {
+	e.compact = enabled
+}
+
+//Encode writes cp to the underlying writer. As with the Write method of the
+//packets, the RemainingLength of cp is updated to the encoded payload length.
+func (e *Encoder) Encode(cp ControlPacket) error {
+	bp := bufferPool.Get().(*[]byte)
+	defer putBuffer(bp)
+	b, err := appendPacket((*bp)[:0], cp)
+	*bp = b
+	if err != nil {
+		return err
+	}
+	if e.setVersion {
+		b[5] = e.version
+	}
+	if e.fragmentSize == 0 || len(b)-fixedHeaderLength <= e.fragmentSize || b[7]&FlagFragment != 0 {
+		return e.writeFrame(bp)
+	}
+
+	var fh FixedHeader
+	fh.unpack(b)
+	payload := b[fixedHeaderLength:]
+	count := fragmentCount(len(payload), e.fragmentSize)
+	if count > math.MaxUint16 {
+		return fmt.Errorf("payload of %d bytes needs %d fragments of %d bytes, at most %d are allowed", len(payload), count, e.fragmentSize, math.MaxUint16)
+	}
+	fbp := bufferPool.Get().(*[]byte)
+	defer putBuffer(fbp)
+	for i := 0; i < count; i++ {
+		*fbp = appendFragmentFrame((*fbp)[:0], fh, payload, e.fragmentSize, i, count)
+		if err := e.writeFrame(fbp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//writeFrame compresses, encrypts, compacts and checksums the frame held in
+//the pooled buffer bp as configured and writes it
+func (e *Encoder) writeFrame(bp *[]byte) error {
+	b := *bp
+	var err error
+	//last is the pooled buffer currently holding b
+	last := bp
+	if e.compression != 0 && len(b)-fixedHeaderLength >= e.compressionThreshold && b[7]&FlagCompressionMask == 0 {
+		cbp := bufferPool.Get().(*[]byte)
+		defer putBuffer(cbp)
+		b, err = compressFrame((*cbp)[:0], b, e.compression)
+		*cbp = b
+		if err != nil {
+			return err
+		}
+		last = cbp
+	}
+	if e.cipher != nil && len(b) > fixedHeaderLength {
+		ebp := bufferPool.Get().(*[]byte)
+		defer putBuffer(ebp)
+		b, err = e.cipher.sealFrame((*ebp)[:0], b)
+		*ebp = b
+		if err != nil {
+			return err
+		}
+		last = ebp
+	}
+	if e.checksum {
+		b[7] |= FlagChecksum
+	}
+	if e.compact {
+		b = compactFrame(b)
+	}
+	if e.checksum {
+		b = appendChecksum(b)
+	}
+	*last = b
+	_, err = e.w.Write(b)
+	return err
+}
+
+func putBuffer(bp *[]byte) {
+	if cap(*bp) <= maxPooledBufferSize {
+		*bp = (*bp)[:0]
+		bufferPool.Put(bp)
+	}
+}
+
+//appendPacket appends the frame of cp to b. Proto-backed packets are
+//marshalled directly into b, any other packet is written into it.
+func appendPacket(b []byte, cp ControlPacket) ([]byte, error) {
+	if pp, ok := cp.(protoPacket); ok {
+		return appendProto(b, pp.header(), pp.message())
+	}
+	w := appendWriter(b)
+	err := cp.Write(&w)
+	return w, err
+}
+
+//appendWriter is an io.Writer appending to a byte slice
+type appendWriter []byte
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
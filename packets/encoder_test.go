@@ -0,0 +1,82 @@
+package packets
+
+import (
+	"bytes"
+	"testing"
+)
+
+//countingWriter records the number of Write calls
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestEncoder(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	jsonLogin := NewControlPacket(Loginreq).(*LoginreqPacket)
+	jsonLogin.Format = FormatJson
+	jsonLogin.UserId = "user"
+	packets := []ControlPacket{
+		NewControlPacket(Pingreq),
+		login,
+		jsonLogin,
+		NewControlPacket(Disconnect),
+	}
+	for _, packet := range packets {
+		w := new(countingWriter)
+		if err := NewEncoder(w).Encode(packet); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", packet, err)
+		}
+		if w.writes != 1 {
+			t.Errorf("Encode of %T issued %d writes, should issue 1", packet, w.writes)
+		}
+		expected := new(bytes.Buffer)
+		packet.Write(expected)
+		if !bytes.Equal(w.Bytes(), expected.Bytes()) {
+			t.Errorf("Encode of %T did not match Write.\nExpected: %x\n     Got: %x", packet, expected.Bytes(), w.Bytes())
+		}
+		read, err := ReadPacket(&w.Buffer)
+		if err != nil {
+			t.Fatalf("Read of encoded %T returned error: %s", packet, err)
+		}
+		if read.String() != packet.String() {
+			t.Errorf("Read of encoded %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+		}
+	}
+}
+
+func BenchmarkEncodeLoginreq(b *testing.B) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	w := new(bytes.Buffer)
+	e := NewEncoder(w)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		if err := e.Encode(login); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteLoginreq(b *testing.B) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	w := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		if err := login.Write(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
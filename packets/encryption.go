@@ -0,0 +1,176 @@
+package packets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/bitstreamstudio/im-packets/protocol"
+)
+
+//FlagEncrypted marks a payload encrypted with the session Cipher. It is
+//applied after compression, so a frame may carry both.
+const FlagEncrypted = 0x04
+
+//ErrDecryptFailed is matched by every DecryptError
+var ErrDecryptFailed = errors.New("payload decryption failed")
+
+var (
+	errNotEncrypted      = errors.New("payload not encrypted")
+	errNoCipher          = errors.New("no cipher set for encrypted payload")
+	errEncryptedTooShort = errors.New("encrypted payload too short")
+)
+
+//DecryptError is returned when the payload of a frame cannot be decrypted,
+//because it was encrypted with another key, was tampered with, or is
+//missing the encryption the session requires
+type DecryptError struct {
+	MessageType byte
+	MsqSeq      uint32
+	Err         error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("tcp protocol package %s msgSeq:%d payload decryption failed: %s", PacketName(e.MessageType), e.MsqSeq, e.Err)
+}
+
+func (e *DecryptError) Unwrap() error {
+	return e.Err
+}
+
+//Is reports ErrDecryptFailed as matching
+func (e *DecryptError) Is(target error) bool {
+	return target == ErrDecryptFailed
+}
+
+//Cipher encrypts payloads with AES-GCM under a session key. Each payload
+//is prefixed with a random nonce. The MessageType, MsqSeq, Version, Format
+//and Flag of the header are authenticated along with it, except for
+//FlagChecksum which is set after encryption, so a payload cannot be
+//replayed under another packet type or sequence number nor read with
+//another codec, version upgrade or compression. RemainingLength is not
+//authenticated, a frame whose length was changed fails to decrypt. A
+//Cipher is safe for concurrent use.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+//NewCipher returns a Cipher using key, which must be 16, 24 or 32 bytes
+//long to select AES-128, AES-192 or AES-256. Sessions would usually get
+//their key from a KeyExchange.
+func NewCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+//additionalData returns the header fields authenticated with the payload
+func additionalData(fh FixedHeader) []byte {
+	b := appendUint32([]byte{fh.MessageType}, fh.MsqSeq)
+	return append(b, fh.Version, fh.Format, fh.Flag&^FlagChecksum)
+}
+
+//sealFrame appends frame to dst with its payload encrypted, setting
+//FlagEncrypted and RemainingLength in the header
+func (c *Cipher) sealFrame(dst, frame []byte) ([]byte, error) {
+	var fh FixedHeader
+	fh.unpack(frame)
+	payload := frame[fixedHeaderLength:]
+	fh.Flag |= FlagEncrypted
+	fh.RemainingLength = uint32(c.aead.NonceSize() + len(payload) + c.aead.Overhead())
+	dst = fh.appendTo(dst)
+	start := len(dst)
+	dst = append(dst, make([]byte, c.aead.NonceSize())...)
+	nonce := dst[start:]
+	if _, err := rand.Read(nonce); err != nil {
+		return dst[:start-fixedHeaderLength], err
+	}
+	return c.aead.Seal(dst, nonce, payload, additionalData(fh)), nil
+}
+
+//open appends the decrypted payload of the frame with header fh to dst
+func (c *Cipher) open(dst []byte, fh FixedHeader, payload []byte) ([]byte, error) {
+	if len(payload) < c.aead.NonceSize()+c.aead.Overhead() {
+		return nil, &DecryptError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errEncryptedTooShort}
+	}
+	nonce, ciphertext := payload[:c.aead.NonceSize()], payload[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(dst, nonce, ciphertext, additionalData(fh))
+	if err != nil {
+		return nil, &DecryptError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: err}
+	}
+	return plaintext, nil
+}
+
+var curves = map[protocol.KeyExchange_Curve]ecdh.Curve{
+	protocol.KeyExchange_X25519: ecdh.X25519(),
+	protocol.KeyExchange_P256:   ecdh.P256(),
+	protocol.KeyExchange_P384:   ecdh.P384(),
+	protocol.KeyExchange_P521:   ecdh.P521(),
+}
+
+//KeyExchange is one side of the ECDH key agreement establishing the key of
+//a session. Right after the client has written its LoginreqPacket, both
+//sides create a KeyExchange on the same curve and send each other its
+//Packet. Once the peer's KeyexchangePacket has been read, Cipher derives
+//the session key, to be set on the Encoder and Decoder of the connection.
+type KeyExchange struct {
+	curve   protocol.KeyExchange_Curve
+	private *ecdh.PrivateKey
+}
+
+//NewKeyExchange generates a key pair on curve
+func NewKeyExchange(curve protocol.KeyExchange_Curve) (*KeyExchange, error) {
+	c, ok := curves[curve]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key exchange curve %v", curve)
+	}
+	private, err := c.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyExchange{curve: curve, private: private}, nil
+}
+
+//Packet returns the KeyexchangePacket carrying the public key to the peer
+func (kx *KeyExchange) Packet() *KeyexchangePacket {
+	kp := NewControlPacket(Keyexchange).(*KeyexchangePacket)
+	kp.Curve = kx.curve
+	kp.PublicKey = kx.private.PublicKey().Bytes()
+	return kp
+}
+
+//Cipher derives the session Cipher from the KeyexchangePacket of the peer.
+//Both sides derive the same AES-256 key, whichever of them calls it.
+func (kx *KeyExchange) Cipher(peer *KeyexchangePacket) (*Cipher, error) {
+	if peer.Curve != kx.curve {
+		return nil, fmt.Errorf("key exchange curve mismatch: %v, peer uses %v", kx.curve, peer.Curve)
+	}
+	peerKey, err := curves[kx.curve].NewPublicKey(peer.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := kx.private.ECDH(peerKey)
+	if err != nil {
+		return nil, err
+	}
+	local, remote := kx.private.PublicKey().Bytes(), peer.PublicKey
+	if bytes.Compare(local, remote) > 0 {
+		local, remote = remote, local
+	}
+	h := sha256.New()
+	h.Write([]byte("im-packets session key"))
+	h.Write(secret)
+	h.Write(local)
+	h.Write(remote)
+	return NewCipher(h.Sum(nil))
+}
@@ -0,0 +1,203 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"strings"
+	"testing"
+)
+
+func testCiphers(t *testing.T, curve protocol.KeyExchange_Curve) (*Cipher, *Cipher) {
+	client, err := NewKeyExchange(curve)
+	if err != nil {
+		t.Fatalf("NewKeyExchange(%v) returned error: %s", curve, err)
+	}
+	server, err := NewKeyExchange(curve)
+	if err != nil {
+		t.Fatalf("NewKeyExchange(%v) returned error: %s", curve, err)
+	}
+
+	b := new(bytes.Buffer)
+	if err := client.Packet().Write(b); err != nil {
+		t.Fatalf("Write of KeyexchangePacket returned error: %s", err)
+	}
+	if err := server.Packet().Write(b); err != nil {
+		t.Fatalf("Write of KeyexchangePacket returned error: %s", err)
+	}
+	fromClient, err := ReadPacket(b)
+	if err != nil {
+		t.Fatalf("Read of KeyexchangePacket returned error: %s", err)
+	}
+	fromServer, err := ReadPacket(b)
+	if err != nil {
+		t.Fatalf("Read of KeyexchangePacket returned error: %s", err)
+	}
+
+	clientCipher, err := client.Cipher(fromServer.(*KeyexchangePacket))
+	if err != nil {
+		t.Fatalf("Cipher on %v returned error: %s", curve, err)
+	}
+	serverCipher, err := server.Cipher(fromClient.(*KeyexchangePacket))
+	if err != nil {
+		t.Fatalf("Cipher on %v returned error: %s", curve, err)
+	}
+	return clientCipher, serverCipher
+}
+
+func TestKeyExchange(t *testing.T) {
+	for _, curve := range []protocol.KeyExchange_Curve{protocol.KeyExchange_X25519, protocol.KeyExchange_P256, protocol.KeyExchange_P384, protocol.KeyExchange_P521} {
+		clientCipher, serverCipher := testCiphers(t, curve)
+		login := NewControlPacket(Loginreq).(*LoginreqPacket)
+		login.MsqSeq = 7
+		login.UserId = "user"
+
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetCipher(clientCipher)
+		if err := e.Encode(login); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", login, err)
+		}
+		d := NewDecoder(b)
+		d.SetCipher(serverCipher)
+		read, err := d.Decode()
+		if err != nil {
+			t.Fatalf("%v: Decode of encrypted %T returned error: %s", curve, login, err)
+		}
+		if read.String() != login.String() {
+			t.Errorf("%v: Decode of encrypted %T did not equal original.\nExpected: %v\n     Got: %v", curve, login, login, read)
+		}
+	}
+}
+
+func TestKeyExchangeCurveMismatch(t *testing.T) {
+	client, _ := NewKeyExchange(protocol.KeyExchange_X25519)
+	server, _ := NewKeyExchange(protocol.KeyExchange_P256)
+	if _, err := client.Cipher(server.Packet()); err == nil {
+		t.Errorf("Cipher with a peer on another curve returned no error")
+	}
+	if _, err := NewKeyExchange(protocol.KeyExchange_Curve(42)); err == nil {
+		t.Errorf("NewKeyExchange with an unknown curve returned no error")
+	}
+}
+
+func TestEncoderEncryption(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("NewCipher returned error: %s", err)
+	}
+	text := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+	text.MsqSeq = 1
+	text.Body = &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Content: strings.Repeat("secret ", 100)}}
+	for _, compression := range []byte{0, FlagCompressGzip} {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetCipher(c)
+		e.SetCompression(compression, 0)
+		if err := e.Encode(text); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", text, err)
+		}
+		frame := b.Bytes()
+		if frame[7] != FlagEncrypted|compression {
+			t.Errorf("Flag of the encrypted frame is 0x%x, should be 0x%x", frame[7], FlagEncrypted|compression)
+		}
+		if bytes.Contains(frame, []byte("secret")) {
+			t.Errorf("Encrypted frame contains the plaintext")
+		}
+
+		d := NewDecoder(b)
+		d.SetCipher(c)
+		read, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode of encrypted %T returned error: %s", text, err)
+		}
+		if read.String() != text.String() {
+			t.Errorf("Decode of encrypted %T did not equal original.\nExpected: %v\n     Got: %v", text, text, read)
+		}
+	}
+}
+
+func TestEncoderEncryptionPayloadless(t *testing.T) {
+	c, _ := NewCipher(bytes.Repeat([]byte{0x42}, 16))
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	e.SetCipher(c)
+	if err := e.Encode(NewControlPacket(Pingreq)); err != nil {
+		t.Fatalf("Encode of PingreqPacket returned error: %s", err)
+	}
+	if b.Len() != fixedHeaderLength {
+		t.Errorf("Encrypted PingreqPacket is %d bytes, should be left as its %d byte header", b.Len(), fixedHeaderLength)
+	}
+	d := NewDecoder(b)
+	d.SetCipher(c)
+	if _, err := d.Decode(); err != nil {
+		t.Errorf("Decode of PingreqPacket returned error: %s", err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key, wrongKey := bytes.Repeat([]byte{0x42}, 32), bytes.Repeat([]byte{0x43}, 32)
+	c, _ := NewCipher(key)
+	wrong, _ := NewCipher(wrongKey)
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.MsqSeq = 7
+	login.UserId = "user"
+
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	e.SetCipher(c)
+	if err := e.Encode(login); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", login, err)
+	}
+	encrypted := b.Bytes()
+	plain := new(bytes.Buffer)
+	login.Write(plain)
+
+	tamperedSeq := append([]byte(nil), encrypted...)
+	tamperedSeq[4]++
+	tamperedType := append([]byte(nil), encrypted...)
+	tamperedType[0] = Logoutreq
+	tamperedVersion := append([]byte(nil), encrypted...)
+	tamperedVersion[5] = 7
+	tamperedFormat := append([]byte(nil), encrypted...)
+	tamperedFormat[6] = FormatMsgpack
+	tamperedFlag := append([]byte(nil), encrypted...)
+	tamperedFlag[7] |= FlagCompressGzip
+	tamperedPayload := append([]byte(nil), encrypted...)
+	tamperedPayload[len(tamperedPayload)-1] ^= 0xFF
+	truncated := append([]byte(nil), encrypted[:fixedHeaderLength+4]...)
+	truncated[11] = 4
+
+	tests := []struct {
+		name   string
+		frame  []byte
+		cipher *Cipher
+	}{
+		{"wrong key", encrypted, wrong},
+		{"tampered MsqSeq", tamperedSeq, c},
+		{"tampered MessageType", tamperedType, c},
+		{"tampered Version", tamperedVersion, c},
+		{"tampered Format", tamperedFormat, c},
+		{"tampered Flag", tamperedFlag, c},
+		{"tampered payload", tamperedPayload, c},
+		{"too short", truncated, c},
+		{"no cipher", encrypted, nil},
+		{"not encrypted", plain.Bytes(), c},
+	}
+	for _, test := range tests {
+		d := NewDecoder(bytes.NewReader(test.frame))
+		d.SetCipher(test.cipher)
+		cp, err := d.Decode()
+		if cp != nil {
+			t.Errorf("%s: Decode returned %v, should return nil", test.name, cp)
+		}
+		var decryptErr *DecryptError
+		if !errors.As(err, &decryptErr) || !errors.Is(err, ErrDecryptFailed) {
+			t.Errorf("%s: Decode returned %v, should return a DecryptError", test.name, err)
+			continue
+		}
+		if decryptErr.MsqSeq != uint32(test.frame[4]) {
+			t.Errorf("%s: DecryptError.MsqSeq is %d, should be %d", test.name, decryptErr.MsqSeq, test.frame[4])
+		}
+	}
+}
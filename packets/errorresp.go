@@ -0,0 +1,41 @@
+package packets
+
+//NewErrorResponse creates the ErrorrespPacket rejecting req with code, one
+//of the error code constants, and msg as the reason. The reply echoes the
+//MsqSeq, Version and Format of the request and names its packet type, so
+//the client can match it with the request it sent. It panics for code
+//Accepted, which rejects nothing.
+func NewErrorResponse(req ControlPacket, code byte, msg string) *ErrorrespPacket {
+	if code == Accepted {
+		panic("error response with code Accepted")
+	}
+	er := NewControlPacket(Errorresp).(*ErrorrespPacket)
+	if h, ok := req.(interface{ header() *FixedHeader }); ok {
+		fh := h.header()
+		er.MsqSeq = fh.MsqSeq
+		er.Version = fh.Version
+		er.Format = fh.Format
+		er.RequestType = uint32(fh.MessageType)
+	}
+	er.Code = uint32(code)
+	er.Reason = msg
+	return er
+}
+
+//Err returns the rejection as a ProtocolError for the rejected request,
+//matching the sentinel of its code with errors.Is. Codes outside the error
+//code space are reported as ErrProtocolViolation, Accepted returns nil.
+func (er *ErrorrespPacket) Err() error {
+	if er.Code == Accepted {
+		return nil
+	}
+	code := byte(er.Code)
+	if er.Code > 0xFF {
+		code = ErrProtocolViolation
+	}
+	pe := NewProtocolError(code, FixedHeader{MessageType: byte(er.RequestType), MsqSeq: er.MsqSeq}, nil)
+	if er.Reason != "" {
+		pe.Message = er.Reason
+	}
+	return pe
+}
@@ -0,0 +1,49 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: errorresp.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Errorresp = 10
+)
+
+func init() {
+	mustRegisterPacketType(Errorresp, "ERRORRESP", func(fh FixedHeader) ControlPacket {
+		return &ErrorrespPacket{FixedHeader: fh}
+	})
+}
+
+// ErrorrespPacket is an internal representation of the fields of the
+// Errorresp TCP packet.
+// Sent by the server to reject a single request while keeping the
+// connection open, its FixedHeader carries the MsqSeq of the rejected
+// request, see packets.NewErrorResponse.
+type ErrorrespPacket struct {
+	FixedHeader
+	protocol.ErrorResp
+}
+
+func (er *ErrorrespPacket) String() string {
+	return fmt.Sprintf("%s %s", er.FixedHeader.String(), er.ErrorResp.String())
+}
+
+func (er *ErrorrespPacket) Write(w io.Writer) error {
+	return writeProto(&er.FixedHeader, &er.ErrorResp, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (er *ErrorrespPacket) Unpack(b io.Reader) error {
+	return unpackProto(&er.FixedHeader, &er.ErrorResp, b)
+}
+
+func (er *ErrorrespPacket) message() proto.Message {
+	return &er.ErrorResp
+}
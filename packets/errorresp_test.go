@@ -0,0 +1,47 @@
+package packets
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorrespPacket(t *testing.T) {
+	req := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+	req.MsqSeq = 42
+	req.Version = 3
+	req.Format = FormatJson
+	resp := NewErrorResponse(req, ErrRefusedNotAuthorised, "unknown receiver")
+	if resp.MsqSeq != req.MsqSeq || resp.Version != req.Version || resp.Format != req.Format {
+		t.Errorf("Header is %v, should echo request %v", resp.FixedHeader, req.FixedHeader)
+	}
+	if resp.RequestType != Peermsgsendreq || resp.Code != ErrRefusedNotAuthorised {
+		t.Errorf("%T rejects type 0x%x with code 0x%x", resp, resp.RequestType, resp.Code)
+	}
+
+	err := resp.Err()
+	var pe *ProtocolError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrNotAuthorised) || pe.MessageType != Peermsgsendreq || pe.MsqSeq != 42 {
+		t.Fatalf("Err is %v, should be ErrNotAuthorised for %s msgSeq:42", err, PacketName(Peermsgsendreq))
+	}
+	expected := "tcp protocol package PEERMSGSENDREQ msgSeq:42 unknown receiver"
+	if err.Error() != expected {
+		t.Errorf("Error() is %q, should be %q", err.Error(), expected)
+	}
+
+	resp.Code = 0x1FF
+	if err := resp.Err(); !errors.Is(err, ErrProtocolViolated) {
+		t.Errorf("Err of code 0x%x is %v, should be ErrProtocolViolated", resp.Code, err)
+	}
+
+	resp.Code = Accepted
+	if err := resp.Err(); err != nil {
+		t.Errorf("Err of code Accepted is %v, should be nil", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewErrorResponse with code Accepted did not panic")
+		}
+	}()
+	NewErrorResponse(req, Accepted, "")
+}
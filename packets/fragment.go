@@ -0,0 +1,221 @@
+package packets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+//FlagFragment marks a frame carrying one fragment of a packet too large
+//for a single frame. The fragments of a packet share its MessageType,
+//MsqSeq, Version and Format, their payload starts with a fragment header
+//of fragmentHeaderLength bytes: the index of the fragment and the number
+//of fragments as uint16 followed by the length of the whole payload as
+//uint32. Compression, encryption and checksums apply to each fragment
+//frame on its own.
+const FlagFragment = 0x10
+
+//fragmentHeaderLength is the encoded length of the fragment header
+const fragmentHeaderLength = 8
+
+//ErrReassemblyFailed is matched by every FragmentError
+var ErrReassemblyFailed = errors.New("packet reassembly failed")
+
+//ErrFragmentTimeout is wrapped by the FragmentError of a packet whose
+//fragments did not all arrive in time
+var ErrFragmentTimeout = errors.New("fragments timed out")
+
+var (
+	errReassemblyDisabled = errors.New("reassembly not enabled")
+	errFragmentTooShort   = errors.New("fragment shorter than its header")
+)
+
+//FragmentError is returned when the fragments of a packet cannot be
+//reassembled. The fragments already received are dropped, the stream
+//itself stays in sync.
+type FragmentError struct {
+	MessageType byte
+	MsqSeq      uint32
+	Err         error
+}
+
+func (e *FragmentError) Error() string {
+	return fmt.Sprintf("tcp protocol package %s msgSeq:%d reassembly failed: %s", PacketName(e.MessageType), e.MsqSeq, e.Err)
+}
+
+func (e *FragmentError) Unwrap() error {
+	return e.Err
+}
+
+//Is reports ErrReassemblyFailed as matching
+func (e *FragmentError) Is(target error) bool {
+	return target == ErrReassemblyFailed
+}
+
+//Fragment splits cp into fragment frames carrying at most size bytes of its
+//payload each. Writing the fragments one by one, e.g. with an Encoder,
+//lets other packets such as pings be sent in between. A Decoder with
+//SetReassembly enabled returns cp once it has read all of them.
+func Fragment(cp ControlPacket, size int) ([]*RawPacket, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid fragment size %d", size)
+	}
+	frame, err := appendPacket(nil, cp)
+	if err != nil {
+		return nil, err
+	}
+	var fh FixedHeader
+	fh.unpack(frame)
+	payload := frame[fixedHeaderLength:]
+	count := fragmentCount(len(payload), size)
+	if count > math.MaxUint16 {
+		return nil, fmt.Errorf("payload of %d bytes needs %d fragments of %d bytes, at most %d are allowed", len(payload), count, size, math.MaxUint16)
+	}
+	fragments := make([]*RawPacket, count)
+	for i := range fragments {
+		b := appendFragmentFrame(nil, fh, payload, size, i, count)
+		fragments[i] = &RawPacket{Payload: b[fixedHeaderLength:]}
+		fragments[i].FixedHeader.unpack(b)
+	}
+	return fragments, nil
+}
+
+//fragmentCount returns the number of fragments of size bytes needed for a
+//payload of length bytes, an empty payload still takes one fragment
+func fragmentCount(length, size int) int {
+	if length == 0 {
+		return 1
+	}
+	return (length + size - 1) / size
+}
+
+//fragmentChunk returns the share of payload carried by fragment i
+func fragmentChunk(payload []byte, size, i int) []byte {
+	end := (i + 1) * size
+	if end > len(payload) {
+		end = len(payload)
+	}
+	return payload[i*size : end]
+}
+
+func appendFragmentHeader(b []byte, index, count, length int) []byte {
+	b = append(b, encodeUint16(uint16(index))...)
+	b = append(b, encodeUint16(uint16(count))...)
+	return appendUint32(b, uint32(length))
+}
+
+//appendFragmentFrame appends fragment i of the frame with header fh and
+//payload to dst
+func appendFragmentFrame(dst []byte, fh FixedHeader, payload []byte, size, i, count int) []byte {
+	chunk := fragmentChunk(payload, size, i)
+	fh.Flag |= FlagFragment
+	fh.RemainingLength = uint32(fragmentHeaderLength + len(chunk))
+	dst = fh.appendTo(dst)
+	dst = appendFragmentHeader(dst, i, count, len(payload))
+	return append(dst, chunk...)
+}
+
+//fragmentKey identifies the packet a fragment belongs to
+type fragmentKey struct {
+	messageType byte
+	msqSeq      uint32
+}
+
+//partialPacket holds the fragments of a packet received so far
+type partialPacket struct {
+	fh       FixedHeader
+	count    uint16
+	next     uint16
+	length   uint32
+	payload  []byte
+	deadline time.Time
+}
+
+//reassembler collects the fragments of packets for a Decoder. The lengths
+//announced by all packets being reassembled together may not exceed
+//maxLength, each packet has timeout from its first fragment to its last.
+type reassembler struct {
+	maxLength uint32
+	pending   uint32
+	timeout   time.Duration
+	now       func() time.Time
+	packets   map[fragmentKey]*partialPacket
+}
+
+func newReassembler(maxLength uint32, timeout time.Duration) *reassembler {
+	return &reassembler{
+		maxLength: maxLength,
+		timeout:   timeout,
+		now:       time.Now,
+		packets:   make(map[fragmentKey]*partialPacket),
+	}
+}
+
+//add adds the fragment with header fh and payload. Once all fragments of
+//the packet have arrived it returns the header and payload of the packet
+//and true.
+func (r *reassembler) add(fh FixedHeader, payload []byte) (FixedHeader, []byte, bool, error) {
+	key := fragmentKey{messageType: fh.MessageType, msqSeq: fh.MsqSeq}
+	if len(payload) < fragmentHeaderLength {
+		r.drop(key)
+		return fh, nil, false, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: errFragmentTooShort}
+	}
+	index := binary.BigEndian.Uint16(payload[0:2])
+	count := binary.BigEndian.Uint16(payload[2:4])
+	length := binary.BigEndian.Uint32(payload[4:8])
+	chunk := payload[fragmentHeaderLength:]
+
+	p, ok := r.packets[key]
+	if !ok {
+		if index != 0 {
+			return fh, nil, false, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: fmt.Errorf("first fragment received is %d of %d", index, count)}
+		}
+		if length > r.maxLength-r.pending {
+			return fh, nil, false, &PayloadLengthError{MessageType: fh.MessageType, Length: r.pending + length, Limit: r.maxLength}
+		}
+		fh.Flag &^= FlagFragment
+		p = &partialPacket{fh: fh, count: count, length: length, deadline: r.now().Add(r.timeout)}
+		r.packets[key] = p
+		r.pending += length
+	} else if index != p.next || count != p.count || length != p.length {
+		r.drop(key)
+		return fh, nil, false, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: fmt.Errorf("fragment %d of %d for %d bytes follows fragment %d of %d for %d bytes", index, count, length, p.next-1, p.count, p.length)}
+	}
+	if uint32(len(p.payload)+len(chunk)) > p.length {
+		r.drop(key)
+		return fh, nil, false, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: fmt.Errorf("fragments exceed the announced %d bytes", p.length)}
+	}
+	p.payload = append(p.payload, chunk...)
+	p.next++
+	if p.next < p.count {
+		return fh, nil, false, nil
+	}
+	r.drop(key)
+	if uint32(len(p.payload)) != p.length {
+		return fh, nil, false, &FragmentError{MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: fmt.Errorf("fragments hold %d bytes, %d were announced", len(p.payload), p.length)}
+	}
+	p.fh.RemainingLength = p.length
+	return p.fh, p.payload, true, nil
+}
+
+//expire drops the first packet found past its deadline and returns its
+//FragmentError, or nil if no packet has timed out
+func (r *reassembler) expire() error {
+	now := r.now()
+	for key, p := range r.packets {
+		if now.After(p.deadline) {
+			r.drop(key)
+			return &FragmentError{MessageType: key.messageType, MsqSeq: key.msqSeq, Err: ErrFragmentTimeout}
+		}
+	}
+	return nil
+}
+
+func (r *reassembler) drop(key fragmentKey) {
+	if p, ok := r.packets[key]; ok {
+		r.pending -= p.length
+		delete(r.packets, key)
+	}
+}
@@ -0,0 +1,155 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"strings"
+	"testing"
+	"time"
+)
+
+func largePeerMsg(seq uint32, length int) *PeermsgsendreqPacket {
+	msg := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+	msg.MsqSeq = seq
+	msg.Sender = "alice"
+	msg.Receiver = "bob"
+	msg.Body = &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Content: strings.Repeat("x", length)}}
+	return msg
+}
+
+func TestEncoderFragments(t *testing.T) {
+	c, _ := NewCipher(bytes.Repeat([]byte{0x42}, 32))
+	msg := largePeerMsg(5, MAX_PAYLOAD_LENGTH_3MB+1000)
+	for _, cipher := range []*Cipher{nil, c} {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetFragmentSize(1024 * 1024)
+		e.SetCipher(cipher)
+		e.SetChecksum(true)
+		if err := e.Encode(msg); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", msg, err)
+		}
+
+		d := NewDecoder(b)
+		d.SetCipher(cipher)
+		d.SetReassembly(8*1024*1024, time.Minute)
+		read, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode of fragmented %T returned error: %s", msg, err)
+		}
+		readMsg, ok := read.(*PeermsgsendreqPacket)
+		if !ok {
+			t.Fatalf("Decode returned %T, should be *PeermsgsendreqPacket", read)
+		}
+		if readMsg.FixedHeader != msg.FixedHeader || readMsg.GetText().GetContent() != msg.GetText().GetContent() {
+			t.Errorf("Decode of fragmented %T did not equal original.\nExpected: %v\n     Got: %v", msg, msg.FixedHeader, readMsg.FixedHeader)
+		}
+		if b.Len() != 0 {
+			t.Errorf("Decode of fragmented %T left %d bytes unread", msg, b.Len())
+		}
+	}
+}
+
+func TestFragmentInterleaved(t *testing.T) {
+	first := largePeerMsg(1, 300)
+	second := largePeerMsg(2, 200)
+	firstFragments, err := Fragment(first, 100)
+	if err != nil {
+		t.Fatalf("Fragment returned error: %s", err)
+	}
+	secondFragments, err := Fragment(second, 100)
+	if err != nil {
+		t.Fatalf("Fragment returned error: %s", err)
+	}
+	if len(firstFragments) != 4 {
+		t.Errorf("Fragment returned %d fragments, should return 4", len(firstFragments))
+	}
+
+	stream := new(bytes.Buffer)
+	e := NewEncoder(stream)
+	for i, fragment := range firstFragments {
+		e.Encode(fragment)
+		if i < len(secondFragments) {
+			e.Encode(secondFragments[i])
+		}
+		e.Encode(NewControlPacket(Pingreq))
+	}
+
+	d := NewDecoder(stream)
+	d.SetReassembly(1024, time.Minute)
+	var pings, msgs int
+	for stream.Len() > 0 {
+		cp, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode of interleaved fragments returned error: %s", err)
+		}
+		switch p := cp.(type) {
+		case *PeermsgsendreqPacket:
+			msgs++
+			if p.MsqSeq == 1 && p.GetText().GetContent() != first.GetText().GetContent() ||
+				p.MsqSeq == 2 && p.GetText().GetContent() != second.GetText().GetContent() {
+				t.Errorf("Reassembled packet msgSeq:%d does not equal original", p.MsqSeq)
+			}
+		case *PingreqPacket:
+			pings++
+		default:
+			t.Errorf("Decode returned unexpected %T", cp)
+		}
+	}
+	if pings != len(firstFragments) || msgs != 2 {
+		t.Errorf("Decode returned %d pings and %d messages, should return %d pings and 2 messages", pings, msgs, len(firstFragments))
+	}
+}
+
+func TestFragmentErrors(t *testing.T) {
+	msg := largePeerMsg(9, 300)
+	fragments, _ := Fragment(msg, 100)
+	encode := func(cps ...ControlPacket) *bytes.Buffer {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		for _, cp := range cps {
+			if err := e.Encode(cp); err != nil {
+				t.Fatalf("Encode of %T returned error: %s", cp, err)
+			}
+		}
+		return b
+	}
+
+	d := NewDecoder(encode(fragments[0]))
+	if _, err := d.Decode(); !errors.Is(err, ErrReassemblyFailed) {
+		t.Errorf("Decode of a fragment without reassembly returned %v, should return a FragmentError", err)
+	}
+
+	d = NewDecoder(encode(fragments[0]))
+	d.SetReassembly(100, time.Minute)
+	if _, err := d.Decode(); !errors.Is(err, ErrOutMaxPayloadLength) {
+		t.Errorf("Decode of fragments above the cap returned %v, should return a PayloadLengthError", err)
+	}
+
+	d = NewDecoder(encode(fragments[0], fragments[2]))
+	d.SetReassembly(1024, time.Minute)
+	if _, err := d.Decode(); !errors.Is(err, ErrReassemblyFailed) {
+		t.Errorf("Decode of fragments out of order returned %v, should return a FragmentError", err)
+	}
+
+	now := time.Unix(0, 0)
+	d = NewDecoder(encode(fragments[0], fragments[1], NewControlPacket(Pingreq), fragments[2]))
+	d.SetReassembly(1024, time.Minute)
+	d.fragments.now = func() time.Time { return now }
+	if cp, err := d.Decode(); err != nil || cp.(*PingreqPacket) == nil {
+		t.Fatalf("Decode of the interleaved ping returned %v, %v", cp, err)
+	}
+	now = now.Add(2 * time.Minute)
+	_, err := d.Decode()
+	var fragmentErr *FragmentError
+	if !errors.As(err, &fragmentErr) || !errors.Is(err, ErrFragmentTimeout) {
+		t.Fatalf("Decode after the timeout returned %v, should return a FragmentError wrapping ErrFragmentTimeout", err)
+	}
+	if fragmentErr.MessageType != Peermsgsendreq || fragmentErr.MsqSeq != 9 {
+		t.Errorf("FragmentError is for %s msgSeq:%d, should be for %s msgSeq:9", PacketName(fragmentErr.MessageType), fragmentErr.MsqSeq, PacketName(Peermsgsendreq))
+	}
+	if d.fragments.pending != 0 {
+		t.Errorf("Timed out reassembly still holds %d bytes", d.fragments.pending)
+	}
+}
@@ -0,0 +1,75 @@
+package packets
+
+import (
+	"bytes"
+	"testing"
+)
+
+//The seed corpus in testdata/fuzz is built from the frames of
+//shortReadPackets, run the targets with e.g.
+//
+//	go test ./packets -run XXX -fuzz FuzzReadPacket
+
+func FuzzReadPacket(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cp, err := ReadPacket(bytes.NewReader(data))
+		if err != nil {
+			if cp != nil {
+				t.Errorf("ReadPacket returned %T along with error %s", cp, err)
+			}
+			return
+		}
+		if err := cp.Write(new(bytes.Buffer)); err != nil {
+			t.Errorf("Write of decoded %T returned error: %s", cp, err)
+		}
+	})
+}
+
+func FuzzFixedHeaderUnpack(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) < fixedHeaderLength {
+			return
+		}
+		var fh FixedHeader
+		fh.unpack(data)
+		if res := fh.appendTo(nil); !bytes.Equal(res, data[:fixedHeaderLength]) {
+			t.Errorf("unpack of [0x%X] did not round trip, got [0x%X]", data[:fixedHeaderLength], res)
+		}
+		_ = fh.String()
+	})
+}
+
+func FuzzUnpack(f *testing.F) {
+	f.Fuzz(func(t *testing.T, packetType byte, format byte, payload []byte) {
+		fh := FixedHeader{MessageType: packetType, Format: format, RemainingLength: uint32(len(payload))}
+		cp, err := NewControlPacketWithHeader(fh)
+		if err != nil {
+			return
+		}
+		if err := cp.Unpack(bytes.NewReader(payload)); err != nil {
+			return
+		}
+		_ = cp.String()
+	})
+}
+
+func FuzzUnpackHeader(f *testing.F) {
+	f.Add([]byte{compactHeaderMarker, Pingreq, 0, CurrentVersion, 1, 0})
+	f.Add([]byte{compactHeaderMarker, Loginreq, FormatJson<<5 | FlagChecksum, 3, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F, 0x80, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fh, n, err := unpackHeader(data)
+		if err != nil || n == 0 {
+			return
+		}
+		if n > len(data) || n > maxCompactHeaderLength {
+			t.Fatalf("unpackHeader of [0x%X] returned length %d", data, n)
+		}
+		if data[0] != compactHeaderMarker {
+			return
+		}
+		res := fh.appendCompactTo(nil)
+		if refh, m, err := unpackHeader(res); err != nil || m != len(res) || refh != fh {
+			t.Errorf("compact header %v of [0x%X] did not round trip, got %v", fh, data[:n], refh)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"github.com/golang/protobuf/proto"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/json")
+
+func TestJsonGolden(t *testing.T) {
+	golden := []struct {
+		name    string
+		codec   JsonCodec
+		message proto.Message
+	}{
+		{"loginreq", JsonCodec{}, &protocol.LoginReq{UserId: "user", Token: "token"}},
+		{"loginresp_error", JsonCodec{}, &protocol.LoginResp{Code: protocol.LoginResp_ERROR}},
+		{"loginresp_ok_unpopulated", JsonCodec{EmitUnpopulated: true}, &protocol.LoginResp{Code: protocol.LoginResp_OK}},
+		{"kickoutreq", JsonCodec{}, &protocol.KickoutReq{Reason: protocol.KickoutReq_OTHER_DEVICE_LOGIN}},
+		{"logoutreq", JsonCodec{}, &protocol.LogoutReq{}},
+		{"peermsgsendreq_text", JsonCodec{}, &protocol.PeerMsgSendReq{
+			Sender:      "alice",
+			Receiver:    "bob",
+			ClientMsgId: "c1",
+			Timestamp:   1600000000000,
+			Body:        &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Type: protocol.MessageText_Markdown, Content: "**hi**"}},
+		}},
+		{"peermsgsendreq_image_unpopulated", JsonCodec{EmitUnpopulated: true}, &protocol.PeerMsgSendReq{
+			Body: &protocol.PeerMsgSendReq_Image{Image: &protocol.MessageImage{SrcUrl: "s", SrcSize: 1024}},
+		}},
+	}
+	for _, g := range golden {
+		res, err := g.codec.Marshal(nil, g.message)
+		if err != nil {
+			t.Fatalf("%s: Marshal returned error: %s", g.name, err)
+		}
+		//protojson randomly varies whitespace between builds, so the golden
+		//files hold the compacted form
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, res); err != nil {
+			t.Fatalf("%s: Marshal returned invalid JSON %s: %s", g.name, res, err)
+		}
+		path := filepath.Join("testdata", "json", g.name+".golden")
+		if *update {
+			if err := ioutil.WriteFile(path, append(compact.Bytes(), '\n'), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = bytes.TrimSuffix(expected, []byte("\n"))
+		if !bytes.Equal(compact.Bytes(), expected) {
+			t.Errorf("%s: Marshal returned %s, should be %s", g.name, compact.Bytes(), expected)
+		}
+
+		read := proto.Clone(g.message)
+		read.Reset()
+		if err := g.codec.Unmarshal(expected, read); err != nil || !proto.Equal(read, g.message) {
+			t.Errorf("%s: Unmarshal of %s returned (%v, %v)", g.name, expected, read, err)
+		}
+	}
+}
+
+func TestJsonCodecUnmarshal(t *testing.T) {
+	inputs := map[string]bool{
+		`{"userId":"user","token":"token"}`:                   true,
+		`{"user_id":"user","token":"token"}`:                  true,
+		`{"userId":"user","token":"token","device":"ios"}`:    false,
+		`{"userId":"user","token":"token","unknown":{"a":1}}`: false,
+	}
+	expected := &protocol.LoginReq{UserId: "user", Token: "token"}
+	for input, known := range inputs {
+		m := new(protocol.LoginReq)
+		if err := (JsonCodec{DiscardUnknown: true}).Unmarshal([]byte(input), m); err != nil || !proto.Equal(m, expected) {
+			t.Errorf("Unmarshal of %s discarding unknown fields returned (%v, %v)", input, m, err)
+		}
+		err := JsonCodec{}.Unmarshal([]byte(input), new(protocol.LoginReq))
+		if known && err != nil {
+			t.Errorf("Unmarshal of %s returned error: %s", input, err)
+		}
+		if !known && err == nil {
+			t.Errorf("Unmarshal of %s with unknown fields did not return an error", input)
+		}
+	}
+
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.Format = FormatJson
+	login.UserId = "user"
+	b := new(bytes.Buffer)
+	login.Write(b)
+	if payload := b.Bytes()[fixedHeaderLength:]; !bytes.Contains(payload, []byte(`"userId"`)) {
+		t.Errorf("FormatJson payload %s does not use the proto3 JSON mapping", payload)
+	}
+}
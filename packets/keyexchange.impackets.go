@@ -0,0 +1,48 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: keyexchange.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Keyexchange = 9
+)
+
+func init() {
+	mustRegisterPacketType(Keyexchange, "KEYEXCHANGE", func(fh FixedHeader) ControlPacket {
+		return &KeyexchangePacket{FixedHeader: fh}
+	})
+}
+
+// KeyexchangePacket is an internal representation of the fields of the
+// Keyexchange TCP packet.
+// Sent by both sides right after the LoginreqPacket to agree on the key
+// encrypting the payloads of the session, see packets.KeyExchange.
+type KeyexchangePacket struct {
+	FixedHeader
+	protocol.KeyExchange
+}
+
+func (ke *KeyexchangePacket) String() string {
+	return fmt.Sprintf("%s %s", ke.FixedHeader.String(), ke.KeyExchange.String())
+}
+
+func (ke *KeyexchangePacket) Write(w io.Writer) error {
+	return writeProto(&ke.FixedHeader, &ke.KeyExchange, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (ke *KeyexchangePacket) Unpack(b io.Reader) error {
+	return unpackProto(&ke.FixedHeader, &ke.KeyExchange, b)
+}
+
+func (ke *KeyexchangePacket) message() proto.Message {
+	return &ke.KeyExchange
+}
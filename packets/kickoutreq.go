@@ -0,0 +1,12 @@
+package packets
+
+import (
+	"github.com/bitstreamstudio/im-packets/protocol"
+)
+
+//NewKickoutreqPacket creates a KickoutreqPacket carrying the given reason
+func NewKickoutreqPacket(reason protocol.KickoutReq_Reason) *KickoutreqPacket {
+	kr := NewControlPacket(Kickoutreq).(*KickoutreqPacket)
+	kr.Reason = reason
+	return kr
+}
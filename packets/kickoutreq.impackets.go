@@ -0,0 +1,48 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: kickoutreq.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Kickoutreq = 6
+)
+
+func init() {
+	mustRegisterPacketType(Kickoutreq, "KICKOUTREQ", func(fh FixedHeader) ControlPacket {
+		return &KickoutreqPacket{FixedHeader: fh}
+	})
+}
+
+// KickoutreqPacket is an internal representation of the fields of the
+// Kickoutreq TCP packet.
+// A server writes it right before closing the connection, the Reason
+// tells the client why its session ended.
+type KickoutreqPacket struct {
+	FixedHeader
+	protocol.KickoutReq
+}
+
+func (kr *KickoutreqPacket) String() string {
+	return fmt.Sprintf("%s %s", kr.FixedHeader.String(), kr.KickoutReq.String())
+}
+
+func (kr *KickoutreqPacket) Write(w io.Writer) error {
+	return writeProto(&kr.FixedHeader, &kr.KickoutReq, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (kr *KickoutreqPacket) Unpack(b io.Reader) error {
+	return unpackProto(&kr.FixedHeader, &kr.KickoutReq, b)
+}
+
+func (kr *KickoutreqPacket) message() proto.Message {
+	return &kr.KickoutReq
+}
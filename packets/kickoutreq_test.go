@@ -0,0 +1,22 @@
+package packets
+
+import (
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"testing"
+)
+
+func TestKickoutreqPacket(t *testing.T) {
+	reasons := []protocol.KickoutReq_Reason{
+		protocol.KickoutReq_ADMIN_OPT,
+		protocol.KickoutReq_OTHER_DEVICE_LOGIN,
+	}
+	for _, format := range []byte{FormatProto, FormatJson} {
+		for _, reason := range reasons {
+			packet := NewKickoutreqPacket(reason)
+			packet.Format = format
+			if read := readBack(t, packet).(*KickoutreqPacket); read.GetReason() != reason {
+				t.Errorf("Reason is %v, should be %v", read.GetReason(), reason)
+			}
+		}
+	}
+}
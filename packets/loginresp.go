@@ -0,0 +1,17 @@
+package packets
+
+import (
+	"github.com/bitstreamstudio/im-packets/protocol"
+)
+
+//NewLoginrespPacket creates the reply to a LoginreqPacket. The reply
+//echoes the MsqSeq, Version and Format of the request so the client can
+//match it with the request it sent.
+func NewLoginrespPacket(req *LoginreqPacket, code protocol.LoginResp_Status) *LoginrespPacket {
+	lr := NewControlPacket(Loginresp).(*LoginrespPacket)
+	lr.MsqSeq = req.MsqSeq
+	lr.Version = req.Version
+	lr.Format = req.Format
+	lr.Code = code
+	return lr
+}
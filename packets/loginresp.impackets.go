@@ -0,0 +1,46 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: loginresp.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Loginresp = 5
+)
+
+func init() {
+	mustRegisterPacketType(Loginresp, "LOGINRESP", func(fh FixedHeader) ControlPacket {
+		return &LoginrespPacket{FixedHeader: fh}
+	})
+}
+
+// LoginrespPacket is an internal representation of the fields of the
+// Loginresp TCP packet
+type LoginrespPacket struct {
+	FixedHeader
+	protocol.LoginResp
+}
+
+func (lr *LoginrespPacket) String() string {
+	return fmt.Sprintf("%s %s", lr.FixedHeader.String(), lr.LoginResp.String())
+}
+
+func (lr *LoginrespPacket) Write(w io.Writer) error {
+	return writeProto(&lr.FixedHeader, &lr.LoginResp, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (lr *LoginrespPacket) Unpack(b io.Reader) error {
+	return unpackProto(&lr.FixedHeader, &lr.LoginResp, b)
+}
+
+func (lr *LoginrespPacket) message() proto.Message {
+	return &lr.LoginResp
+}
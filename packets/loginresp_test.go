@@ -0,0 +1,20 @@
+package packets
+
+import (
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"testing"
+)
+
+func TestLoginrespPacket(t *testing.T) {
+	req := NewControlPacket(Loginreq).(*LoginreqPacket)
+	req.MsqSeq = 42
+	req.Version = 3
+	req.Format = FormatJson
+	resp := NewLoginrespPacket(req, protocol.LoginResp_ERROR)
+	if resp.MsqSeq != req.MsqSeq || resp.Version != req.Version || resp.Format != req.Format {
+		t.Errorf("Header is %v, should echo request %v", resp.FixedHeader, req.FixedHeader)
+	}
+	if resp.Code != protocol.LoginResp_ERROR {
+		t.Errorf("Code is %v, should be %v", resp.Code, protocol.LoginResp_ERROR)
+	}
+}
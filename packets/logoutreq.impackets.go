@@ -0,0 +1,49 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: logoutreq.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Logoutreq = 7
+)
+
+func init() {
+	mustRegisterPacketType(Logoutreq, "LOGOUTREQ", func(fh FixedHeader) ControlPacket {
+		return &LogoutreqPacket{FixedHeader: fh}
+	})
+}
+
+// LogoutreqPacket is an internal representation of the fields of the
+// Logoutreq TCP packet.
+// Unlike DisconnectPacket, which only closes the transport, it ends the
+// login session and the server is expected to invalidate the token that
+// was used in the LoginreqPacket.
+type LogoutreqPacket struct {
+	FixedHeader
+	protocol.LogoutReq
+}
+
+func (lr *LogoutreqPacket) String() string {
+	return fmt.Sprintf("%s %s", lr.FixedHeader.String(), lr.LogoutReq.String())
+}
+
+func (lr *LogoutreqPacket) Write(w io.Writer) error {
+	return writeProto(&lr.FixedHeader, &lr.LogoutReq, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (lr *LogoutreqPacket) Unpack(b io.Reader) error {
+	return unpackProto(&lr.FixedHeader, &lr.LogoutReq, b)
+}
+
+func (lr *LogoutreqPacket) message() proto.Message {
+	return &lr.LogoutReq
+}
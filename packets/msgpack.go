@@ -0,0 +1,556 @@
+package packets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math"
+	"unicode/utf8"
+)
+
+//msgpackMaxDepth bounds the nesting of messages, arrays and maps accepted
+//by MsgpackCodec.Unmarshal
+const msgpackMaxDepth = 64
+
+var (
+	errMsgpackTruncated = errors.New("msgpack: unexpected end of data")
+	errMsgpackDepth     = errors.New("msgpack: exceeded max nesting depth")
+)
+
+//MsgpackCodec is the Codec of FormatMsgpack, meant for clients that cannot
+//afford a protobuf runtime. A message is encoded as a MessagePack map from
+//proto field names to values. Only populated fields are written, enums are
+//integers, bytes fields are bin, repeated fields arrays and map fields
+//maps. Field names unknown to the message are skipped when decoding.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(b []byte, m proto.Message) ([]byte, error) {
+	return appendMsgpackMessage(b, proto.MessageReflect(m)), nil
+}
+
+func (MsgpackCodec) Unmarshal(b []byte, m proto.Message) error {
+	m.Reset()
+	d := msgpackDecoder{b: b}
+	if err := d.message(proto.MessageReflect(m), 0); err != nil {
+		return err
+	}
+	if d.off != len(d.b) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(d.b)-d.off)
+	}
+	return nil
+}
+
+func appendMsgpackMessage(b []byte, m protoreflect.Message) []byte {
+	var n int
+	m.Range(func(protoreflect.FieldDescriptor, protoreflect.Value) bool {
+		n++
+		return true
+	})
+	b = appendMsgpackHeader(b, n, 0x80, 0xde, 0xdf)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		b = appendMsgpackString(b, string(fd.Name()))
+		switch {
+		case fd.IsList():
+			l := v.List()
+			b = appendMsgpackHeader(b, l.Len(), 0x90, 0xdc, 0xdd)
+			for i := 0; i < l.Len(); i++ {
+				b = appendMsgpackValue(b, fd, l.Get(i))
+			}
+		case fd.IsMap():
+			mv := v.Map()
+			b = appendMsgpackHeader(b, mv.Len(), 0x80, 0xde, 0xdf)
+			mv.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				b = appendMsgpackValue(b, fd.MapKey(), k.Value())
+				b = appendMsgpackValue(b, fd.MapValue(), v)
+				return true
+			})
+		default:
+			b = appendMsgpackValue(b, fd, v)
+		}
+		return true
+	})
+	return b
+}
+
+func appendMsgpackValue(b []byte, fd protoreflect.FieldDescriptor, v protoreflect.Value) []byte {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case protoreflect.EnumKind:
+		return appendMsgpackInt(b, int64(v.Enum()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return appendMsgpackInt(b, v.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return appendMsgpackUint(b, v.Uint())
+	case protoreflect.FloatKind:
+		return appendUint32(append(b, 0xca), math.Float32bits(float32(v.Float())))
+	case protoreflect.DoubleKind:
+		b = append(b, 0xcb)
+		return append(b, encodeUint64(math.Float64bits(v.Float()))...)
+	case protoreflect.StringKind:
+		return appendMsgpackString(b, v.String())
+	case protoreflect.BytesKind:
+		b = appendMsgpackLength(b, len(v.Bytes()), 0xc4, 0xc5, 0xc6)
+		return append(b, v.Bytes()...)
+	default:
+		return appendMsgpackMessage(b, v.Message())
+	}
+}
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendMsgpackUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return append(b, 0xd1, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return append(append(b, 0xd3), encodeUint64(uint64(i))...)
+	}
+}
+
+func appendMsgpackUint(b []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return append(b, 0xcd, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(u))
+	default:
+		return append(append(b, 0xcf), encodeUint64(u)...)
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	if len(s) < 32 {
+		b = append(b, 0xa0|byte(len(s)))
+	} else {
+		b = appendMsgpackLength(b, len(s), 0xd9, 0xda, 0xdb)
+	}
+	return append(b, s...)
+}
+
+//appendMsgpackHeader appends the header of an array or map of n entries,
+//fix being the type byte of the 4 bit form
+func appendMsgpackHeader(b []byte, n int, fix, type16, type32 byte) []byte {
+	if n < 16 {
+		return append(b, fix|byte(n))
+	}
+	if n <= math.MaxUint16 {
+		return append(b, type16, byte(n>>8), byte(n))
+	}
+	return appendUint32(append(b, type32), uint32(n))
+}
+
+//appendMsgpackLength appends the header of a str or bin of n bytes
+func appendMsgpackLength(b []byte, n int, type8, type16, type32 byte) []byte {
+	switch {
+	case n <= math.MaxUint8:
+		return append(b, type8, byte(n))
+	case n <= math.MaxUint16:
+		return append(b, type16, byte(n>>8), byte(n))
+	default:
+		return appendUint32(append(b, type32), uint32(n))
+	}
+}
+
+func encodeUint64(num uint64) []byte {
+	bytesResult := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytesResult, num)
+	return bytesResult
+}
+
+//msgpackDecoder decodes the MessagePack encoding in b, starting at off
+type msgpackDecoder struct {
+	b   []byte
+	off int
+}
+
+func (d *msgpackDecoder) next() (byte, error) {
+	if d.off >= len(d.b) {
+		return 0, errMsgpackTruncated
+	}
+	c := d.b[d.off]
+	d.off++
+	return c, nil
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.b)-d.off {
+		return nil, errMsgpackTruncated
+	}
+	b := d.b[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+//readUint reads a big endian unsigned integer of n bytes
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+//length reads a length encoded in n bytes and checks that at least
+//min*length bytes are left for the entries it announces
+func (d *msgpackDecoder) length(n, min int) (int, error) {
+	u, err := d.readUint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(d.b)-d.off) || int(u)*min > len(d.b)-d.off {
+		return 0, errMsgpackTruncated
+	}
+	return int(u), nil
+}
+
+func (d *msgpackDecoder) mapLen() (int, error) {
+	c, err := d.next()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), nil
+	case c == 0xde:
+		return d.length(2, 2)
+	case c == 0xdf:
+		return d.length(4, 2)
+	}
+	return 0, fmt.Errorf("msgpack: expected map, got 0x%x", c)
+}
+
+func (d *msgpackDecoder) arrayLen() (int, error) {
+	c, err := d.next()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c&0xf0 == 0x90:
+		return int(c & 0x0f), nil
+	case c == 0xdc:
+		return d.length(2, 1)
+	case c == 0xdd:
+		return d.length(4, 1)
+	}
+	return 0, fmt.Errorf("msgpack: expected array, got 0x%x", c)
+}
+
+//raw reads a str or bin
+func (d *msgpackDecoder) raw() ([]byte, error) {
+	c, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case c&0xe0 == 0xa0:
+		return d.read(int(c & 0x1f))
+	case c == 0xc4 || c == 0xd9:
+		n, err = d.length(1, 1)
+	case c == 0xc5 || c == 0xda:
+		n, err = d.length(2, 1)
+	case c == 0xc6 || c == 0xdb:
+		n, err = d.length(4, 1)
+	default:
+		return nil, fmt.Errorf("msgpack: expected str or bin, got 0x%x", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d.read(n)
+}
+
+//integer reads any integer type, returning its two's complement bits and
+//whether it is negative
+func (d *msgpackDecoder) integer() (uint64, bool, error) {
+	c, err := d.next()
+	if err != nil {
+		return 0, false, err
+	}
+	switch {
+	case c <= 0x7f:
+		return uint64(c), false, nil
+	case c >= 0xe0:
+		return uint64(int64(int8(c))), true, nil
+	case c >= 0xcc && c <= 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		return u, false, err
+	case c >= 0xd0 && c <= 0xd3:
+		n := 1 << (c - 0xd0)
+		u, err := d.readUint(n)
+		if err != nil {
+			return 0, false, err
+		}
+		shift := uint(64 - 8*n)
+		i := int64(u<<shift) >> shift
+		return uint64(i), i < 0, nil
+	}
+	return 0, false, fmt.Errorf("msgpack: expected integer, got 0x%x", c)
+}
+
+func (d *msgpackDecoder) float() (float64, error) {
+	if d.off < len(d.b) {
+		switch d.b[d.off] {
+		case 0xca:
+			d.off++
+			u, err := d.readUint(4)
+			return float64(math.Float32frombits(uint32(u))), err
+		case 0xcb:
+			d.off++
+			u, err := d.readUint(8)
+			return math.Float64frombits(u), err
+		}
+	}
+	u, negative, err := d.integer()
+	if negative {
+		return float64(int64(u)), err
+	}
+	return float64(u), err
+}
+
+//scalar decodes a value of any kind but messages
+func (d *msgpackDecoder) scalar(fd protoreflect.FieldDescriptor) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		c, err := d.next()
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		if c != 0xc2 && c != 0xc3 {
+			return protoreflect.Value{}, fmt.Errorf("msgpack: expected bool for %s, got 0x%x", fd.Name(), c)
+		}
+		return protoreflect.ValueOfBool(c == 0xc3), nil
+	case protoreflect.FloatKind:
+		f, err := d.float()
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := d.float()
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		b, err := d.raw()
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		if !utf8.Valid(b) {
+			return protoreflect.Value{}, fmt.Errorf("msgpack: invalid UTF-8 in %s", fd.Name())
+		}
+		return protoreflect.ValueOfString(string(b)), nil
+	case protoreflect.BytesKind:
+		b, err := d.raw()
+		return protoreflect.ValueOfBytes(append([]byte(nil), b...)), err
+	}
+
+	u, negative, err := d.integer()
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	i := int64(u)
+	var inRange bool
+	var v protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		inRange = (negative || u <= math.MaxInt32) && i >= math.MinInt32
+		if fd.Kind() == protoreflect.EnumKind {
+			v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(i))
+		} else {
+			v = protoreflect.ValueOfInt32(int32(i))
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		inRange = negative || u <= math.MaxInt64
+		v = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		inRange = !negative && u <= math.MaxUint32
+		v = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		inRange = !negative
+		v = protoreflect.ValueOfUint64(u)
+	}
+	if !inRange {
+		return protoreflect.Value{}, fmt.Errorf("msgpack: value out of range for %s", fd.Name())
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) message(m protoreflect.Message, depth int) error {
+	if depth > msgpackMaxDepth {
+		return errMsgpackDepth
+	}
+	n, err := d.mapLen()
+	if err != nil {
+		return err
+	}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < n; i++ {
+		name, err := d.raw()
+		if err != nil {
+			return err
+		}
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			if err := d.skip(depth); err != nil {
+				return err
+			}
+			continue
+		}
+		if d.off < len(d.b) && d.b[d.off] == 0xc0 {
+			d.off++
+			continue
+		}
+		if err := d.field(m, fd, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) field(m protoreflect.Message, fd protoreflect.FieldDescriptor, depth int) error {
+	isMessage := func(fd protoreflect.FieldDescriptor) bool {
+		return fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind
+	}
+	switch {
+	case fd.IsList():
+		n, err := d.arrayLen()
+		if err != nil {
+			return err
+		}
+		l := m.Mutable(fd).List()
+		for i := 0; i < n; i++ {
+			var v protoreflect.Value
+			if isMessage(fd) {
+				v = l.NewElement()
+				err = d.message(v.Message(), depth+1)
+			} else {
+				v, err = d.scalar(fd)
+			}
+			if err != nil {
+				return err
+			}
+			l.Append(v)
+		}
+	case fd.IsMap():
+		n, err := d.mapLen()
+		if err != nil {
+			return err
+		}
+		mv := m.Mutable(fd).Map()
+		for i := 0; i < n; i++ {
+			k, err := d.scalar(fd.MapKey())
+			if err != nil {
+				return err
+			}
+			var v protoreflect.Value
+			if isMessage(fd.MapValue()) {
+				v = mv.NewValue()
+				err = d.message(v.Message(), depth+1)
+			} else {
+				v, err = d.scalar(fd.MapValue())
+			}
+			if err != nil {
+				return err
+			}
+			mv.Set(k.MapKey(), v)
+		}
+	case isMessage(fd):
+		return d.message(m.Mutable(fd).Message(), depth+1)
+	default:
+		v, err := d.scalar(fd)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, v)
+	}
+	return nil
+}
+
+//skip skips over the next value, whatever its type
+func (d *msgpackDecoder) skip(depth int) error {
+	if depth > msgpackMaxDepth {
+		return errMsgpackDepth
+	}
+	c, err := d.next()
+	if err != nil {
+		return err
+	}
+	var n, entries int
+	switch {
+	case c <= 0x7f, c >= 0xe0, c == 0xc0, c == 0xc2, c == 0xc3:
+		return nil
+	case c&0xf0 == 0x80:
+		entries = 2 * int(c&0x0f)
+	case c&0xf0 == 0x90:
+		entries = int(c & 0x0f)
+	case c&0xe0 == 0xa0:
+		_, err = d.read(int(c & 0x1f))
+		return err
+	case c == 0xc4 || c == 0xd9:
+		n, err = d.length(1, 1)
+	case c == 0xc5 || c == 0xda:
+		n, err = d.length(2, 1)
+	case c == 0xc6 || c == 0xdb:
+		n, err = d.length(4, 1)
+	case c == 0xc7:
+		n, err = d.length(1, 1)
+		n++
+	case c == 0xc8:
+		n, err = d.length(2, 1)
+		n++
+	case c == 0xc9:
+		n, err = d.length(4, 1)
+		n++
+	case c == 0xca, c == 0xd2, c == 0xce:
+		n = 4
+	case c == 0xcb, c == 0xd3, c == 0xcf:
+		n = 8
+	case c == 0xcc, c == 0xd0:
+		n = 1
+	case c == 0xcd, c == 0xd1:
+		n = 2
+	case c >= 0xd4 && c <= 0xd8:
+		n = 1 + 1<<(c-0xd4)
+	case c == 0xdc:
+		entries, err = d.length(2, 1)
+	case c == 0xdd:
+		entries, err = d.length(4, 1)
+	case c == 0xde:
+		entries, err = d.length(2, 2)
+		entries *= 2
+	case c == 0xdf:
+		entries, err = d.length(4, 2)
+		entries *= 2
+	default:
+		return fmt.Errorf("msgpack: invalid type 0x%x", c)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := d.read(n); err != nil {
+		return err
+	}
+	for i := 0; i < entries; i++ {
+		if err := d.skip(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
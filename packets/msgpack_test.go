@@ -0,0 +1,115 @@
+package packets
+
+import (
+	"bytes"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"math"
+	"testing"
+)
+
+func TestMsgpackEncoding(t *testing.T) {
+	res, err := MsgpackCodec{}.Marshal(nil, &protocol.LoginReq{UserId: "u"})
+	expected := []byte{0x81, 0xa7, 'u', 's', 'e', 'r', '_', 'i', 'd', 0xa1, 'u'}
+	if err != nil || !bytes.Equal(res, expected) {
+		t.Errorf("Marshal of LoginReq did not return ([0x%X], nil) but ([0x%X], %v)", expected, res, err)
+	}
+
+	ints := map[int64][]byte{
+		0:             {0x00},
+		127:           {0x7f},
+		-32:           {0xe0},
+		-33:           {0xd0, 0xdf},
+		200:           {0xcc, 0xc8},
+		-200:          {0xd1, 0xff, 0x38},
+		70000:         {0xce, 0x00, 0x01, 0x11, 0x70},
+		-70000:        {0xd2, 0xff, 0xfe, 0xee, 0x90},
+		math.MaxInt64: {0xcf, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		math.MinInt64: {0xd3, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}
+	for i, encoded := range ints {
+		if res := appendMsgpackInt(nil, i); !bytes.Equal(res, encoded) {
+			t.Errorf("appendMsgpackInt(%d) did not return [0x%X], but [0x%X]", i, encoded, res)
+		}
+		d := msgpackDecoder{b: encoded}
+		if u, negative, err := d.integer(); int64(u) != i || negative != (i < 0) || err != nil {
+			t.Errorf("integer([0x%X]) did not return %d but (%d, %v, %v)", encoded, i, int64(u), negative, err)
+		}
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	fields, _ := structpb.NewStruct(map[string]interface{}{
+		"name":   "test",
+		"count":  3.5,
+		"nested": map[string]interface{}{"ok": true, "list": []interface{}{1.0, "two", nil}},
+	})
+	messages := []proto.Message{
+		&protocol.LoginReq{UserId: "user", Token: "token"},
+		&protocol.PeerMsgSendReq{
+			Sender:    "alice",
+			Receiver:  "bob",
+			Timestamp: -1,
+			Body:      &protocol.PeerMsgSendReq_Location{Location: &protocol.MessageLocation{Latitude: -33.9, Longitude: 151.2, Address: "Sydney"}},
+		},
+		&descriptorpb.FileDescriptorProto{
+			Name:       proto.String("test.proto"),
+			Dependency: []string{"a.proto", "b.proto"},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Test"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("id"),
+					Number: proto.Int32(math.MaxInt32),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_UINT64.Enum(),
+				}},
+			}},
+			Options: &descriptorpb.FileOptions{JavaMultipleFiles: proto.Bool(false), GoPackage: proto.String(string(make([]byte, 300)))},
+		},
+		fields,
+	}
+	for _, m := range messages {
+		b, err := MsgpackCodec{}.Marshal(nil, m)
+		if err != nil {
+			t.Fatalf("Marshal of %T returned error: %s", m, err)
+		}
+		read := proto.Clone(m)
+		read.Reset()
+		if err := (MsgpackCodec{}).Unmarshal(b, read); err != nil {
+			t.Fatalf("Unmarshal of %T returned error: %s", m, err)
+		}
+		if !proto.Equal(read, m) {
+			t.Errorf("Unmarshal of %T did not equal original.\nExpected: %v\n     Got: %v", m, m, read)
+		}
+	}
+}
+
+func TestMsgpackUnmarshalErrors(t *testing.T) {
+	inputs := map[string][]byte{
+		"truncated":    {0x81, 0xa6, 's', 'e', 'n', 'd', 'e', 'r', 0xa5, 'u'},
+		"not a map":    {0x91, 0x00},
+		"wrong type":   {0x81, 0xa6, 's', 'e', 'n', 'd', 'e', 'r', 0x01},
+		"trailing":     {0x80, 0x00},
+		"huge map":     {0xdf, 0xff, 0xff, 0xff, 0xff},
+		"bad utf8":     {0x81, 0xa6, 's', 'e', 'n', 'd', 'e', 'r', 0xa1, 0xff},
+		"out of range": {0x81, 0xa9, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', 0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	for name, input := range inputs {
+		if err := (MsgpackCodec{}).Unmarshal(input, new(protocol.PeerMsgSendReq)); err == nil {
+			t.Errorf("Unmarshal of %s input did not return an error", name)
+		}
+	}
+
+	deep := bytes.Repeat([]byte{0x91}, 2*msgpackMaxDepth)
+	deep = append([]byte{0x81, 0xa7, 'u', 'n', 'k', 'n', 'o', 'w', 'n'}, append(deep, 0x00)...)
+	if err := (MsgpackCodec{}).Unmarshal(deep, new(protocol.LoginReq)); err != errMsgpackDepth {
+		t.Errorf("Unmarshal of deeply nested input returned %v, should be %v", err, errMsgpackDepth)
+	}
+
+	unknown := []byte{0x82, 0xa3, 'n', 'e', 'w', 0x92, 0xc3, 0xd4, 0x01, 0x02, 0xa5, 't', 'o', 'k', 'e', 'n', 0xa1, 't'}
+	m := new(protocol.LoginReq)
+	if err := (MsgpackCodec{}).Unmarshal(unknown, m); err != nil || m.Token != "t" {
+		t.Errorf("Unmarshal with an unknown field returned (%v, %v), should skip it", m, err)
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"github.com/bitstreamstudio/im-packets/protocol"
 	"io"
 )
 
 const MAX_PAYLOAD_LENGTH_3MB = 3 * 1024 * 1024
 
+//fixedHeaderLength is the encoded length of a FixedHeader: MessageType,
+//MsqSeq, Version, Format, Flag and RemainingLength
+const fixedHeaderLength = 12
+
 //ControlPacket defines the interface for structs intended to hold
 //decoded MQTT packets, either from being read or before being
 //written
@@ -20,8 +23,11 @@ type ControlPacket interface {
 	String() string
 }
 
-//PacketNames maps the constants for each of the MQTT packet types
-//to a string representation of their name.
+//PacketNames maps the constants of the original packet types to a string
+//representation of their name. Packet types added since, including those
+//generated from proto/, are not listed.
+//
+//Deprecated: use PacketName, which knows every registered packet type.
 var PacketNames = map[uint8]string{
 	1: "PINGREQ",
 	2: "PINGRESP",
@@ -29,16 +35,17 @@ var PacketNames = map[uint8]string{
 	4: "LOGINREQ",
 }
 
-//Below are the constants assigned to each of the MQTT packet types
+//Below are the constants assigned to each of the MQTT packet types, the
+//constants of the proto-backed packets live in the generated
+//*.impackets.go files
 const (
 	Pingreq    = 1
 	Pingresp   = 2
 	Disconnect = 3
-	Loginreq   = 4
 )
 
-//Below are the const definitions for error codes returned by
-//Connect()
+//Below are the const definitions for error codes, carried by
+//ProtocolError
 const (
 	Accepted                        = 0x00
 	ErrRefusedBadProtocolVersion    = 0x01
@@ -50,16 +57,43 @@ const (
 	ErrProtocolViolation            = 0xFF
 )
 
+//Below are the payload formats selecting the Codec of a frame, further
+//formats can be added with RegisterCodec
 const (
 	FormatProto   = 0
 	FormatJson    = 1
+	FormatMsgpack = 2
 	FormatDefault = FormatProto
 )
 
+//ErrOutMaxPayloadLength is matched by every PayloadLengthError, the
+//default limit being MAX_PAYLOAD_LENGTH_3MB
 var ErrOutMaxPayloadLength = errors.New("tcp protocol package payload out of max length 3MB")
 
+//PayloadLengthError is returned when a frame announces a RemainingLength
+//above the limit configured for its packet type
+type PayloadLengthError struct {
+	MessageType byte
+	Length      uint32
+	Limit       uint32
+}
+
+func (e *PayloadLengthError) Error() string {
+	return fmt.Sprintf("tcp protocol package %s payload length %d out of max length %d", PacketName(e.MessageType), e.Length, e.Limit)
+}
+
+//Is reports ErrOutMaxPayloadLength as matching, so errors.Is keeps working
+//for callers checking the sentinel
+func (e *PayloadLengthError) Is(target error) bool {
+	return target == ErrOutMaxPayloadLength
+}
+
+var ErrDuplicatePacketType = errors.New("packet type already registered")
+
 //ConnackReturnCodes is a map of the error codes constants for Connect()
 //to a string representation of the error
+//
+//Deprecated: use the Message of the ProtocolError sentinels.
 var ConnackReturnCodes = map[uint8]string{
 	0:   "Connection Accepted",
 	1:   "Connection Refused: Bad Protocol Version",
@@ -72,88 +106,50 @@ var ConnackReturnCodes = map[uint8]string{
 }
 
 //ConnErrors is a map of the errors codes constants for Connect()
-//to a Go error
+//to the sentinel ProtocolError of the code
+//
+//Deprecated: use the sentinels such as ErrBadProtocolVersion directly.
 var ConnErrors = map[byte]error{
 	Accepted:                        nil,
-	ErrRefusedBadProtocolVersion:    errors.New("unnacceptable protocol version"),
-	ErrRefusedIDRejected:            errors.New("identifier rejected"),
-	ErrRefusedServerUnavailable:     errors.New("server Unavailable"),
-	ErrRefusedBadUsernameOrPassword: errors.New("bad user name or password"),
-	ErrRefusedNotAuthorised:         errors.New("not Authorized"),
-	ErrNetworkError:                 errors.New("network Error"),
-	ErrProtocolViolation:            errors.New("protocol Violation"),
+	ErrRefusedBadProtocolVersion:    ErrBadProtocolVersion,
+	ErrRefusedIDRejected:            ErrIDRejected,
+	ErrRefusedServerUnavailable:     ErrServerUnavailable,
+	ErrRefusedBadUsernameOrPassword: ErrBadUsernameOrPassword,
+	ErrRefusedNotAuthorised:         ErrNotAuthorised,
+	ErrNetworkError:                 ErrNetwork,
+	ErrProtocolViolation:            ErrProtocolViolated,
 }
 
 //ReadPacket takes an instance of an io.Reader (such as net.Conn) and attempts
 //to read an MQTT packet from the stream. It returns a ControlPacket
 //representing the decoded MQTT packet and an error. One of these returns will
 //always be nil, a nil ControlPacket indicating an error occurred.
+//Errors other than io.EOF at the end of the stream are a *ProtocolError.
 func ReadPacket(r io.Reader) (ControlPacket, error) {
-	var fh FixedHeader
-	b := make([]byte, 1)
-
-	_, err := io.ReadFull(r, b)
-	if err != nil {
-		return nil, err
-	}
-
-	err = fh.unpack(b[0], r)
-	if err != nil {
-		return nil, err
-	}
-
-	cp, err := NewControlPacketWithHeader(fh)
-	if err != nil {
-		return nil, err
-	}
-
-	packetBytes := make([]byte, fh.RemainingLength)
-	n, err := io.ReadFull(r, packetBytes)
-	if err != nil {
-		return nil, err
-	}
-	if int64(n) != int64(fh.RemainingLength) {
-		return nil, errors.New("failed to read expected data")
-	}
-
-	err = cp.Unpack(bytes.NewBuffer(packetBytes))
-	return cp, err
+	return NewDecoder(r).Decode()
 }
 
 //NewControlPacket is used to create a new ControlPacket of the type specified
 //by packetType, this is usually done by reference to the packet type constants
 //defined in packets.go. The newly created ControlPacket is empty and a pointer
-//is returned.
+//is returned, or nil if packetType has not been registered.
 func NewControlPacket(packetType byte) ControlPacket {
-	switch packetType {
-	case Disconnect:
-		return &DisconnectPacket{FixedHeader: FixedHeader{MessageType: Disconnect}}
-	case Pingreq:
-		return &PingreqPacket{FixedHeader: FixedHeader{MessageType: Pingreq}}
-	case Pingresp:
-		return &PingrespPacket{FixedHeader: FixedHeader{MessageType: Pingresp}}
-	case Loginreq:
-		return &LoginreqPacket{FixedHeader: FixedHeader{MessageType: Loginreq}, LoginReq: protocol.LoginReq{}}
+	pt, ok := lookupPacketType(packetType)
+	if !ok {
+		return nil
 	}
-	return nil
+	return pt.factory(FixedHeader{MessageType: packetType})
 }
 
 //NewControlPacketWithHeader is used to create a new ControlPacket of the type
 //specified within the FixedHeader that is passed to the function.
 //The newly created ControlPacket is empty and a pointer is returned.
 func NewControlPacketWithHeader(fh FixedHeader) (ControlPacket, error) {
-	switch fh.MessageType {
-	case Disconnect:
-		return &DisconnectPacket{FixedHeader: fh}, nil
-	case Pingreq:
-		return &PingreqPacket{FixedHeader: fh}, nil
-	case Pingresp:
-		return &PingrespPacket{FixedHeader: fh}, nil
-	case Loginreq:
-		return &LoginreqPacket{FixedHeader: fh, LoginReq: protocol.LoginReq{}}, nil
+	pt, ok := lookupPacketType(fh.MessageType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported packet type 0x%x", fh.MessageType)
 	}
-
-	return nil, fmt.Errorf("unsupported packet type 0x%x", fh.MessageType)
+	return pt.factory(fh), nil
 }
 
 //FixedHeader is a struct to hold the decoded information from
@@ -168,7 +164,7 @@ type FixedHeader struct {
 }
 
 func (fh FixedHeader) String() string {
-	return fmt.Sprintf("%s: msgSeq:%d version:%d format:%d flag:%d rLength:%d", PacketNames[fh.MessageType], fh.MsqSeq, fh.Version, fh.Format, fh.Flag, fh.RemainingLength)
+	return fmt.Sprintf("%s: msgSeq:%d version:%d format:%d flag:%d rLength:%d", packetLabel(fh.MessageType), fh.MsqSeq, fh.Version, fh.Format, fh.Flag, fh.RemainingLength)
 }
 
 func boolToByte(b bool) byte {
@@ -182,44 +178,48 @@ func boolToByte(b bool) byte {
 
 func (fh *FixedHeader) pack() bytes.Buffer {
 	var header bytes.Buffer
-	header.WriteByte(fh.MessageType)
-	header.Write(encodeUint32(fh.MsqSeq))
-	header.WriteByte(fh.Version)
-	header.WriteByte(fh.Format)
-	header.WriteByte(fh.Flag)
-	header.Write(encodeUint32(fh.RemainingLength))
+	header.Write(fh.appendTo(make([]byte, 0, fixedHeaderLength)))
 	return header
 }
 
-func (fh *FixedHeader) unpack(typeAndFlags byte, r io.Reader) error {
-	fh.MessageType = typeAndFlags
-	var err error
-	fh.MsqSeq, err = decodeUint32(r)
-	if err != nil {
-		return err
-	}
-	fh.Version, err = decodeByte(r)
-	if err != nil {
-		return err
-	}
-	fh.Format, err = decodeByte(r)
-	if err != nil {
-		return err
-	}
-	fh.Flag, err = decodeByte(r)
-	if err != nil {
-		return err
-	}
-	fh.RemainingLength, err = decodeUint32(r)
-	if fh.RemainingLength > MAX_PAYLOAD_LENGTH_3MB {
-		return ErrOutMaxPayloadLength
+//appendTo appends the encoded header to b
+func (fh *FixedHeader) appendTo(b []byte) []byte {
+	b = append(b, fh.MessageType)
+	b = appendUint32(b, fh.MsqSeq)
+	b = append(b, fh.Version, fh.Format, fh.Flag)
+	return appendUint32(b, fh.RemainingLength)
+}
+
+//header gives access to the FixedHeader of any packet embedding it
+func (fh *FixedHeader) header() *FixedHeader {
+	return fh
+}
+
+//unpack decodes the fixed header from b, which must hold
+//fixedHeaderLength bytes. Limits on RemainingLength are left to the caller.
+func (fh *FixedHeader) unpack(b []byte) {
+	fh.MessageType = b[0]
+	fh.MsqSeq = binary.BigEndian.Uint32(b[1:5])
+	fh.Version = b[5]
+	fh.Format = b[6]
+	fh.Flag = b[7]
+	fh.RemainingLength = binary.BigEndian.Uint32(b[8:12])
+}
+
+//readFull reads exactly len(buf) bytes from r, however many Read calls
+//that takes. The fields it is used for sit inside a frame, so running out
+//of input is reported as io.ErrUnexpectedEOF even before the first byte.
+func readFull(r io.Reader, buf []byte) error {
+	_, err := io.ReadFull(r, buf)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
 	}
 	return err
 }
 
 func decodeByte(b io.Reader) (byte, error) {
 	num := make([]byte, 1)
-	_, err := b.Read(num)
+	err := readFull(b, num)
 	if err != nil {
 		return 0, err
 	}
@@ -229,7 +229,7 @@ func decodeByte(b io.Reader) (byte, error) {
 
 func decodeUint16(b io.Reader) (uint16, error) {
 	num := make([]byte, 2)
-	_, err := b.Read(num)
+	err := readFull(b, num)
 	if err != nil {
 		return 0, err
 	}
@@ -238,7 +238,7 @@ func decodeUint16(b io.Reader) (uint16, error) {
 
 func decodeUint32(b io.Reader) (uint32, error) {
 	num := make([]byte, 4)
-	_, err := b.Read(num)
+	err := readFull(b, num)
 	if err != nil {
 		return 0, err
 	}
@@ -257,6 +257,10 @@ func encodeUint32(num uint32) []byte {
 	return bytesResult
 }
 
+func appendUint32(b []byte, num uint32) []byte {
+	return append(b, byte(num>>24), byte(num>>16), byte(num>>8), byte(num))
+}
+
 func encodeString(field string) []byte {
 	return encodeBytes([]byte(field))
 }
@@ -273,7 +277,7 @@ func decodeBytes(b io.Reader) ([]byte, error) {
 	}
 
 	field := make([]byte, fieldLength)
-	_, err = b.Read(field)
+	err = readFull(b, field)
 	if err != nil {
 		return nil, err
 	}
@@ -18,6 +18,11 @@ func TestPacketNames(t *testing.T) {
 	if PacketNames[4] != "LOGINREQ" {
 		t.Errorf("PacketNames[4] is %s, should be %s", PacketNames[5], "LOGINREQ")
 	}
+	for code, name := range PacketNames {
+		if PacketName(code) != name {
+			t.Errorf("PacketName(%d) is %s, should be %s", code, PacketName(code), name)
+		}
+	}
 }
 
 func TestPacketConsts(t *testing.T) {
@@ -33,6 +38,18 @@ func TestPacketConsts(t *testing.T) {
 	if Loginreq != 4 {
 		t.Errorf("Const for Loginreq is %d, should be %d", Loginreq, 6)
 	}
+	if Loginresp != 5 {
+		t.Errorf("Const for Loginresp is %d, should be %d", Loginresp, 5)
+	}
+	if Kickoutreq != 6 {
+		t.Errorf("Const for Kickoutreq is %d, should be %d", Kickoutreq, 6)
+	}
+	if Logoutreq != 7 {
+		t.Errorf("Const for Logoutreq is %d, should be %d", Logoutreq, 7)
+	}
+	if Peermsgsendreq != 8 {
+		t.Errorf("Const for Peermsgsendreq is %d, should be %d", Peermsgsendreq, 8)
+	}
 }
 
 func TestPackUnpackControlPackets(t *testing.T) {
@@ -41,6 +58,10 @@ func TestPackUnpackControlPackets(t *testing.T) {
 		NewControlPacket(Pingresp).(*PingrespPacket),
 		NewControlPacket(Disconnect).(*DisconnectPacket),
 		NewControlPacket(Loginreq).(*LoginreqPacket),
+		NewControlPacket(Loginresp).(*LoginrespPacket),
+		NewControlPacket(Kickoutreq).(*KickoutreqPacket),
+		NewControlPacket(Logoutreq).(*LogoutreqPacket),
+		NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket),
 	}
 	buf := new(bytes.Buffer)
 	for _, packet := range packets {
@@ -102,3 +123,17 @@ func TestEncoding(t *testing.T) {
 		}
 	}
 }
+
+//readBack writes cp and reads it back, failing the test on any error
+func readBack(t *testing.T, cp ControlPacket) ControlPacket {
+	t.Helper()
+	b := new(bytes.Buffer)
+	if err := cp.Write(b); err != nil {
+		t.Fatalf("Write of %T returned error: %s", cp, err)
+	}
+	read, err := ReadPacket(b)
+	if err != nil {
+		t.Fatalf("Read of packed %T returned error: %s", cp, err)
+	}
+	return read
+}
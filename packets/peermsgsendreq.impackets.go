@@ -0,0 +1,48 @@
+// Code generated by protoc-gen-impackets. DO NOT EDIT.
+// source: peermsgsendreq.proto
+
+package packets
+
+import (
+	fmt "fmt"
+	protocol "github.com/bitstreamstudio/im-packets/protocol"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+)
+
+const (
+	Peermsgsendreq = 8
+)
+
+func init() {
+	mustRegisterPacketType(Peermsgsendreq, "PEERMSGSENDREQ", func(fh FixedHeader) ControlPacket {
+		return &PeermsgsendreqPacket{FixedHeader: fh}
+	})
+}
+
+// PeermsgsendreqPacket is an internal representation of the fields of the
+// Peermsgsendreq TCP packet.
+// A one-to-one chat message whose body is one of the message types from
+// messages.proto.
+type PeermsgsendreqPacket struct {
+	FixedHeader
+	protocol.PeerMsgSendReq
+}
+
+func (pr *PeermsgsendreqPacket) String() string {
+	return fmt.Sprintf("%s %s", pr.FixedHeader.String(), pr.PeerMsgSendReq.String())
+}
+
+func (pr *PeermsgsendreqPacket) Write(w io.Writer) error {
+	return writeProto(&pr.FixedHeader, &pr.PeerMsgSendReq, w)
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (pr *PeermsgsendreqPacket) Unpack(b io.Reader) error {
+	return unpackProto(&pr.FixedHeader, &pr.PeerMsgSendReq, b)
+}
+
+func (pr *PeermsgsendreqPacket) message() proto.Message {
+	return &pr.PeerMsgSendReq
+}
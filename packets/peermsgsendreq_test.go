@@ -0,0 +1,33 @@
+package packets
+
+import (
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestPeermsgsendreqPacket(t *testing.T) {
+	bodies := []protocol.PeerMsgSendReq{
+		{Body: &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Type: protocol.MessageText_Markdown, Content: "**hi**"}}},
+		{Body: &protocol.PeerMsgSendReq_Image{Image: &protocol.MessageImage{ThumbUrl: "t", SrcUrl: "s", SrcSize: 1024}}},
+		{Body: &protocol.PeerMsgSendReq_Audio{Audio: &protocol.MessageAudio{SrcUrl: "a", Duration: 3}}},
+		{Body: &protocol.PeerMsgSendReq_Video{Video: &protocol.MessageVideo{SrcUrl: "v", SrcSize: 2048, Duration: 10}}},
+		{Body: &protocol.PeerMsgSendReq_File{File: &protocol.MessageFile{SrcUrl: "f", SrcSize: 1, Type: "pdf"}}},
+		{Body: &protocol.PeerMsgSendReq_Location{Location: &protocol.MessageLocation{Latitude: 1.5, Longitude: 2.5, Address: "here"}}},
+	}
+	for _, format := range []byte{FormatProto, FormatJson} {
+		for i := range bodies {
+			packet := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+			packet.Format = format
+			packet.Sender = "alice"
+			packet.Receiver = "bob"
+			packet.ClientMsgId = "c1"
+			packet.Timestamp = 1600000000000
+			packet.Body = bodies[i].Body
+			read := readBack(t, packet).(*PeermsgsendreqPacket)
+			if !proto.Equal(&read.PeerMsgSendReq, &packet.PeerMsgSendReq) {
+				t.Errorf("Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package packets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+//ProtocolError is returned by ReadPacket and Decoder for every frame that
+//fails to decode. Code is one of the error code constants, MessageType
+//and MsqSeq identify the offending frame as far as its header was read,
+//and Err holds the underlying error, such as a PayloadLengthError or
+//io.ErrUnexpectedEOF. errors.Is matches a ProtocolError against the
+//sentinel of its Code, e.g. ErrBadProtocolVersion.
+type ProtocolError struct {
+	Code        byte
+	Message     string
+	MessageType byte
+	MsqSeq      uint32
+	Err         error
+}
+
+//Below are the sentinel ProtocolErrors of each error code, to be used with
+//errors.Is
+var (
+	ErrBadProtocolVersion    = &ProtocolError{Code: ErrRefusedBadProtocolVersion, Message: "unacceptable protocol version"}
+	ErrIDRejected            = &ProtocolError{Code: ErrRefusedIDRejected, Message: "identifier rejected"}
+	ErrServerUnavailable     = &ProtocolError{Code: ErrRefusedServerUnavailable, Message: "server unavailable"}
+	ErrBadUsernameOrPassword = &ProtocolError{Code: ErrRefusedBadUsernameOrPassword, Message: "bad user name or password"}
+	ErrNotAuthorised         = &ProtocolError{Code: ErrRefusedNotAuthorised, Message: "not authorized"}
+	ErrNetwork               = &ProtocolError{Code: ErrNetworkError, Message: "network error"}
+	ErrProtocolViolated      = &ProtocolError{Code: ErrProtocolViolation, Message: "protocol violation"}
+)
+
+//protocolErrors maps each error code to its sentinel
+var protocolErrors = map[byte]*ProtocolError{
+	ErrRefusedBadProtocolVersion:    ErrBadProtocolVersion,
+	ErrRefusedIDRejected:            ErrIDRejected,
+	ErrRefusedServerUnavailable:     ErrServerUnavailable,
+	ErrRefusedBadUsernameOrPassword: ErrBadUsernameOrPassword,
+	ErrRefusedNotAuthorised:         ErrNotAuthorised,
+	ErrNetworkError:                 ErrNetwork,
+	ErrProtocolViolation:            ErrProtocolViolated,
+}
+
+//NewProtocolError returns a ProtocolError with code for the packet with
+//header fh, its Message taken from the sentinel of code
+func NewProtocolError(code byte, fh FixedHeader, err error) *ProtocolError {
+	pe := &ProtocolError{Code: code, MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: err}
+	if sentinel, ok := protocolErrors[code]; ok {
+		pe.Message = sentinel.Message
+	} else {
+		pe.Message = fmt.Sprintf("error code 0x%x", code)
+	}
+	return pe
+}
+
+func (e *ProtocolError) Error() string {
+	msg := e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.MessageType == 0 && e.MsqSeq == 0 {
+		return msg
+	}
+	return fmt.Sprintf("tcp protocol package %s msgSeq:%d %s", packetLabel(e.MessageType), e.MsqSeq, msg)
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+//Is reports any ProtocolError with the same Code as matching
+func (e *ProtocolError) Is(target error) bool {
+	t, ok := target.(*ProtocolError)
+	return ok && t.Code == e.Code
+}
+
+//packetLabel returns the name of packet type code, or its number if the
+//type is unknown
+func packetLabel(code byte) string {
+	if name := PacketName(code); name != "" {
+		return name
+	}
+	return fmt.Sprintf("0x%X", code)
+}
+
+//protocolError turns err, returned while decoding the frame with header
+//fh, into a ProtocolError. Errors already carrying a code keep it, any
+//other error is a protocol violation.
+func protocolError(fh FixedHeader, err error) *ProtocolError {
+	if pe, ok := err.(*ProtocolError); ok {
+		if pe.MessageType != 0 || pe.MsqSeq != 0 {
+			return pe
+		}
+		return &ProtocolError{Code: pe.Code, Message: pe.Message, MessageType: fh.MessageType, MsqSeq: fh.MsqSeq, Err: pe.Err}
+	}
+	var pe *ProtocolError
+	if errors.As(err, &pe) {
+		return NewProtocolError(pe.Code, fh, err)
+	}
+	var ve *VersionError
+	if errors.As(err, &ve) {
+		return NewProtocolError(ErrRefusedBadProtocolVersion, fh, err)
+	}
+	var fe *FragmentError
+	if errors.As(err, &fe) {
+		fh.MessageType, fh.MsqSeq = fe.MessageType, fe.MsqSeq
+	}
+	return NewProtocolError(ErrProtocolViolation, fh, err)
+}
+
+//readError marks err, returned by the underlying reader, as a network
+//error. io.EOF is only passed on as is by the read of the first byte of a
+//frame.
+func readError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return NewProtocolError(ErrNetworkError, FixedHeader{}, err)
+}
@@ -0,0 +1,84 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadPacketProtocolErrors(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.MsqSeq = 12
+	login.UserId = "user"
+	valid := new(bytes.Buffer)
+	login.Write(valid)
+
+	oversized := FixedHeader{MessageType: Loginreq, MsqSeq: 12, RemainingLength: MAX_PAYLOAD_LENGTH_3MB + 1}
+	unknown := FixedHeader{MessageType: 0xEE, MsqSeq: 12}
+	garbage := FixedHeader{MessageType: Loginreq, MsqSeq: 12, RemainingLength: 2}
+
+	tests := []struct {
+		name  string
+		frame []byte
+		code  byte
+		inner error
+	}{
+		{"truncated", valid.Bytes()[:valid.Len()-1], ErrNetworkError, io.ErrUnexpectedEOF},
+		{"oversized", oversized.appendTo(nil), ErrProtocolViolation, ErrOutMaxPayloadLength},
+		{"unknown type", unknown.appendTo(nil), ErrProtocolViolation, nil},
+		{"bad payload", append(garbage.appendTo(nil), 0xFF, 0xFF), ErrProtocolViolation, nil},
+	}
+	for _, test := range tests {
+		cp, err := ReadPacket(bytes.NewReader(test.frame))
+		if cp != nil {
+			t.Errorf("%s: ReadPacket returned %v, should return nil", test.name, cp)
+		}
+		var pe *ProtocolError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: ReadPacket returned %v, should return a ProtocolError", test.name, err)
+			continue
+		}
+		if pe.Code != test.code || !errors.Is(err, protocolErrors[test.code]) {
+			t.Errorf("%s: ProtocolError code is 0x%x, should be 0x%x", test.name, pe.Code, test.code)
+		}
+		if pe.MsqSeq != 12 {
+			t.Errorf("%s: ProtocolError msgSeq is %d, should be 12", test.name, pe.MsqSeq)
+		}
+		if test.inner != nil && !errors.Is(err, test.inner) {
+			t.Errorf("%s: ReadPacket returned %v, should wrap %v", test.name, err, test.inner)
+		}
+	}
+
+	if _, err := ReadPacket(bytes.NewReader(nil)); err != io.EOF {
+		t.Errorf("ReadPacket of an empty stream returned %v, should be %v", err, io.EOF)
+	}
+}
+
+func TestProtocolErrorSentinels(t *testing.T) {
+	for code, sentinel := range protocolErrors {
+		if ConnErrors[code] != sentinel {
+			t.Errorf("ConnErrors[0x%x] is %v, should be the sentinel %v", code, ConnErrors[code], sentinel)
+		}
+		err := NewProtocolError(code, FixedHeader{MessageType: Logoutreq, MsqSeq: 5}, io.ErrUnexpectedEOF)
+		if !errors.Is(err, sentinel) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ProtocolError with code 0x%x does not match its sentinel and wrapped error", code)
+		}
+		for other, otherSentinel := range protocolErrors {
+			if other != code && errors.Is(err, otherSentinel) {
+				t.Errorf("ProtocolError with code 0x%x matches the sentinel of 0x%x", code, other)
+			}
+		}
+	}
+
+	d := NewDecoder(bytes.NewReader([]byte{Logoutreq}))
+	d.Decode()
+	if ErrNetwork.MessageType != 0 || ErrNetwork.Err != nil {
+		t.Errorf("Decode modified the sentinel ErrNetwork: %v", ErrNetwork)
+	}
+
+	expected := "tcp protocol package LOGOUTREQ msgSeq:5 not authorized: denied"
+	if err := NewProtocolError(ErrRefusedNotAuthorised, FixedHeader{MessageType: Logoutreq, MsqSeq: 5}, errors.New("denied")); err.Error() != expected {
+		t.Errorf("Error() is %q, should be %q", err.Error(), expected)
+	}
+}
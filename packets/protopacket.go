@@ -0,0 +1,110 @@
+package packets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"io"
+)
+
+//protoPacket is implemented by packets whose payload is a single
+//proto.Message, which lets the Encoder marshal the payload straight into
+//its buffer
+type protoPacket interface {
+	ControlPacket
+	header() *FixedHeader
+	message() proto.Message
+}
+
+//ProtoPacket is a ControlPacket whose payload is any proto.Message. It
+//encodes the payload with the Codec selected by Format and keeps
+//RemainingLength in sync, so a proto-backed packet type only needs to be
+//registered with RegisterProtoPacketType.
+type ProtoPacket struct {
+	FixedHeader
+	Message proto.Message
+}
+
+//RegisterProtoPacketType registers a packet type whose payload is the
+//proto.Message returned by newMessage. ReadPacket returns such packets as
+//a *ProtoPacket holding a fresh message from newMessage.
+func RegisterProtoPacketType(code byte, name string, newMessage func() proto.Message) error {
+	if newMessage == nil {
+		return fmt.Errorf("nil message constructor for packet type 0x%x", code)
+	}
+	return RegisterPacketType(code, name, func(fh FixedHeader) ControlPacket {
+		return &ProtoPacket{FixedHeader: fh, Message: newMessage()}
+	})
+}
+
+func (pp *ProtoPacket) String() string {
+	if pp.Message == nil {
+		return pp.FixedHeader.String()
+	}
+	return fmt.Sprintf("%s %s", pp.FixedHeader.String(), pp.Message.String())
+}
+
+func (pp *ProtoPacket) Write(w io.Writer) error {
+	return writeProto(&pp.FixedHeader, pp.Message, w)
+}
+
+//Unpack decodes the details of a ControlPacket after the fixed
+//header has been read
+func (pp *ProtoPacket) Unpack(b io.Reader) error {
+	return unpackProto(&pp.FixedHeader, pp.Message, b)
+}
+
+func (pp *ProtoPacket) message() proto.Message {
+	return pp.Message
+}
+
+//appendPayload appends m to b, encoded by the Codec registered for format
+func appendPayload(format byte, b []byte, m proto.Message) ([]byte, error) {
+	return codecFor(format).Marshal(b, m)
+}
+
+//unmarshalPayload decodes b into m using the Codec registered for format
+func unmarshalPayload(format byte, b []byte, m proto.Message) error {
+	return codecFor(format).Unmarshal(b, m)
+}
+
+//appendProto appends fh followed by m to b, RemainingLength is set to the
+//length of the encoded m
+func appendProto(b []byte, fh *FixedHeader, m proto.Message) ([]byte, error) {
+	if m == nil {
+		return b, fmt.Errorf("no message to encode for packet type %s", packetLabel(fh.MessageType))
+	}
+	start := len(b)
+	b = fh.appendTo(b)
+	b, err := appendPayload(fh.Format, b, m)
+	if err != nil {
+		return b[:start], err
+	}
+	fh.RemainingLength = uint32(len(b) - start - fixedHeaderLength)
+	binary.BigEndian.PutUint32(b[start+fixedHeaderLength-4:], fh.RemainingLength)
+	return b, nil
+}
+
+//writeProto writes fh followed by m in a single Write, RemainingLength is
+//set to the length of the encoded m
+func writeProto(fh *FixedHeader, m proto.Message, w io.Writer) error {
+	bytes, err := appendProto(nil, fh, m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+//unpackProto reads fh.RemainingLength bytes from b and decodes them into m
+func unpackProto(fh *FixedHeader, m proto.Message, b io.Reader) error {
+	if m == nil {
+		return fmt.Errorf("no message to decode for packet type %s", packetLabel(fh.MessageType))
+	}
+	bytes := make([]byte, fh.RemainingLength)
+	err := readFull(b, bytes)
+	if err != nil {
+		return err
+	}
+	return unmarshalPayload(fh.Format, bytes, m)
+}
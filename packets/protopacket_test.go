@@ -0,0 +1,68 @@
+package packets
+
+import (
+	"bytes"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"github.com/golang/protobuf/proto"
+	"testing"
+)
+
+const testProtoPacketType = 0xF1
+
+func init() {
+	if err := RegisterProtoPacketType(testProtoPacketType, "TESTPROTO", func() proto.Message {
+		return new(protocol.MessageText)
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestProtoPacket(t *testing.T) {
+	for _, format := range []byte{FormatProto, FormatJson} {
+		packet := NewControlPacket(testProtoPacketType).(*ProtoPacket)
+		packet.Format = format
+		packet.MsqSeq = 3
+		packet.Message.(*protocol.MessageText).Content = "hello"
+		b := new(bytes.Buffer)
+		if err := packet.Write(b); err != nil {
+			t.Fatalf("Write of %T returned error: %s", packet, err)
+		}
+		if packet.RemainingLength == 0 {
+			t.Errorf("RemainingLength was not set by Write")
+		}
+		read, err := ReadPacket(b)
+		if err != nil {
+			t.Fatalf("Read of packed %T returned error: %s", packet, err)
+		}
+		protoPacket := read.(*ProtoPacket)
+		if !proto.Equal(protoPacket.Message, packet.Message) {
+			t.Errorf("Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+		}
+		if read.String() != packet.String() {
+			t.Errorf("Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+		}
+	}
+}
+
+func TestRegisterProtoPacketTypeNil(t *testing.T) {
+	if err := RegisterProtoPacketType(0xEE, "NIL", nil); err == nil {
+		t.Errorf("RegisterProtoPacketType with a nil constructor did not return an error")
+	}
+}
+
+func TestProtoPacketNilMessage(t *testing.T) {
+	packet := &ProtoPacket{FixedHeader: FixedHeader{MessageType: testProtoPacketType, MsqSeq: 3}}
+	if packet.String() != packet.FixedHeader.String() {
+		t.Errorf("String is %q, should be %q", packet.String(), packet.FixedHeader.String())
+	}
+	b := new(bytes.Buffer)
+	if err := packet.Write(b); err == nil || b.Len() != 0 {
+		t.Errorf("Write without a message returned %v and wrote %d bytes, should fail", err, b.Len())
+	}
+	if err := NewEncoder(b).Encode(packet); err == nil || b.Len() != 0 {
+		t.Errorf("Encode without a message returned %v and wrote %d bytes, should fail", err, b.Len())
+	}
+	if err := packet.Unpack(bytes.NewReader(nil)); err == nil {
+		t.Errorf("Unpack without a message did not return an error")
+	}
+}
@@ -0,0 +1,54 @@
+package packets
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+//RawPacket keeps the FixedHeader and the undecoded payload of a packet.
+//Proxies use it to relay packets they have no type registered for. Write
+//reproduces a plain frame byte for byte. The Decoder has already undone
+//the framing of the connection, so the payload is decrypted,
+//decompressed and reassembled from its fragments, the flags of these and
+//the checksum trailer are dropped and a compact header is written in the
+//full layout. An Encoder configured like the sender's frames it again.
+type RawPacket struct {
+	FixedHeader
+	Payload []byte
+}
+
+func (rp *RawPacket) String() string {
+	return fmt.Sprintf("%s payload:%d bytes", rp.FixedHeader.String(), len(rp.Payload))
+}
+
+func (rp *RawPacket) Write(w io.Writer) error {
+	rp.RemainingLength = uint32(len(rp.Payload))
+	b := rp.FixedHeader.appendTo(make([]byte, 0, fixedHeaderLength+len(rp.Payload)))
+	b = append(b, rp.Payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+//Unpack decodes the details of a ControlPacket after the fixed
+//header has been read
+func (rp *RawPacket) Unpack(b io.Reader) error {
+	rp.Payload = make([]byte, rp.RemainingLength)
+	return readFull(b, rp.Payload)
+}
+
+//Decode decodes the payload into the packet type registered for the
+//MessageType of the header. It fails like NewControlPacketWithHeader if no
+//such type is registered.
+func (rp *RawPacket) Decode() (ControlPacket, error) {
+	fh := rp.FixedHeader
+	fh.RemainingLength = uint32(len(rp.Payload))
+	cp, err := NewControlPacketWithHeader(fh)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.Unpack(bytes.NewReader(rp.Payload)); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
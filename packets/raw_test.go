@@ -0,0 +1,125 @@
+package packets
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRawPacket(t *testing.T) {
+	unknown := &RawPacket{
+		FixedHeader: FixedHeader{MessageType: 0xC0, MsqSeq: 11, Version: 2, Format: FormatJson, Flag: 0x80},
+		Payload:     []byte(`{"new":"field"}`),
+	}
+	frame := new(bytes.Buffer)
+	if err := unknown.Write(frame); err != nil {
+		t.Fatalf("Write of %T returned error: %s", unknown, err)
+	}
+	original := append([]byte(nil), frame.Bytes()...)
+
+	if _, err := ReadPacket(bytes.NewReader(original)); err == nil {
+		t.Errorf("ReadPacket of an unknown type did not return an error")
+	}
+
+	d := NewDecoder(bytes.NewReader(original))
+	d.SetRawUnknown(true)
+	read, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode of an unknown type returned error: %s", err)
+	}
+	rawPacket, ok := read.(*RawPacket)
+	if !ok {
+		t.Fatalf("Decode returned %T, should be *RawPacket", read)
+	}
+	relayed := new(bytes.Buffer)
+	if err := NewEncoder(relayed).Encode(rawPacket); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", rawPacket, err)
+	}
+	if !bytes.Equal(relayed.Bytes(), original) {
+		t.Errorf("Relayed frame is [0x%X], should be [0x%X]", relayed.Bytes(), original)
+	}
+}
+
+func TestRawPacketDecode(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.MsqSeq = 5
+	login.UserId = "user"
+	frame := new(bytes.Buffer)
+	login.Write(frame)
+
+	d := NewDecoder(frame)
+	d.SetRawUnknown(true)
+	read, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error: %s", err)
+	}
+	if _, ok := read.(*LoginreqPacket); !ok {
+		t.Fatalf("Decode of a registered type returned %T, should be *LoginreqPacket", read)
+	}
+
+	raw := &RawPacket{FixedHeader: login.FixedHeader}
+	frame.Reset()
+	login.Write(frame)
+	frame.Next(fixedHeaderLength)
+	if err := raw.Unpack(frame); err != nil {
+		t.Fatalf("Unpack of %T returned error: %s", raw, err)
+	}
+	decoded, err := raw.Decode()
+	if err != nil {
+		t.Fatalf("Decode of %T returned error: %s", raw, err)
+	}
+	if decoded.String() != login.String() {
+		t.Errorf("Decode of %T did not equal original.\nExpected: %v\n     Got: %v", raw, login, decoded)
+	}
+
+	raw.MessageType = 0xC1
+	if _, err := raw.Decode(); err == nil {
+		t.Errorf("Decode of an unknown type did not return an error")
+	}
+}
+
+func TestRawPacketFraming(t *testing.T) {
+	unknown := &RawPacket{
+		FixedHeader: FixedHeader{MessageType: 0xC0, MsqSeq: 11},
+		Payload:     bytes.Repeat([]byte("payload"), 64),
+	}
+	frame := new(bytes.Buffer)
+	e := NewEncoder(frame)
+	e.SetCompression(FlagCompressGzip, 0)
+	e.SetChecksum(true)
+	if err := e.Encode(unknown); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", unknown, err)
+	}
+	original := append([]byte(nil), frame.Bytes()...)
+
+	d := NewDecoder(frame)
+	d.SetRawUnknown(true)
+	read, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode of a compressed unknown type returned error: %s", err)
+	}
+	rawPacket := read.(*RawPacket)
+	if rawPacket.Flag != 0 || !bytes.Equal(rawPacket.Payload, unknown.Payload) {
+		t.Errorf("Decode returned flag 0x%x and payload %q, should return the decompressed payload", rawPacket.Flag, rawPacket.Payload)
+	}
+
+	plain := new(bytes.Buffer)
+	unknown.Write(plain)
+	written := new(bytes.Buffer)
+	if err := rawPacket.Write(written); err != nil {
+		t.Fatalf("Write of %T returned error: %s", rawPacket, err)
+	}
+	if !bytes.Equal(written.Bytes(), plain.Bytes()) {
+		t.Errorf("Write of %T is [0x%X], should be the plain frame [0x%X]", rawPacket, written.Bytes(), plain.Bytes())
+	}
+
+	relayed := new(bytes.Buffer)
+	e = NewEncoder(relayed)
+	e.SetCompression(FlagCompressGzip, 0)
+	e.SetChecksum(true)
+	if err := e.Encode(rawPacket); err != nil {
+		t.Fatalf("Encode of %T returned error: %s", rawPacket, err)
+	}
+	if !bytes.Equal(relayed.Bytes(), original) {
+		t.Errorf("Relayed frame is [0x%X], should be [0x%X]", relayed.Bytes(), original)
+	}
+}
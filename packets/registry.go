@@ -0,0 +1,78 @@
+package packets
+
+import (
+	"fmt"
+	"sync"
+)
+
+//PacketFactory creates an empty ControlPacket for the given FixedHeader.
+//The header must be stored in the returned packet as is, since it carries
+//the RemainingLength needed by Unpack.
+type PacketFactory func(fh FixedHeader) ControlPacket
+
+type packetType struct {
+	name    string
+	factory PacketFactory
+}
+
+//registry holds every packet type known to ReadPacket, NewControlPacket
+//and NewControlPacketWithHeader. It is guarded by a RWMutex so that
+//packet types may be registered while other goroutines are decoding.
+var registry = struct {
+	sync.RWMutex
+	types map[byte]packetType
+}{types: make(map[byte]packetType)}
+
+func init() {
+	mustRegisterPacketType(Pingreq, "PINGREQ", func(fh FixedHeader) ControlPacket {
+		return &PingreqPacket{FixedHeader: fh}
+	})
+	mustRegisterPacketType(Pingresp, "PINGRESP", func(fh FixedHeader) ControlPacket {
+		return &PingrespPacket{FixedHeader: fh}
+	})
+	mustRegisterPacketType(Disconnect, "DISCONNECT", func(fh FixedHeader) ControlPacket {
+		return &DisconnectPacket{FixedHeader: fh}
+	})
+}
+
+//RegisterPacketType makes a packet type available to ReadPacket,
+//NewControlPacket and NewControlPacketWithHeader. The code is the
+//MessageType carried in the FixedHeader and name is what String() prints
+//for it. Registering a code that is already in use returns
+//ErrDuplicatePacketType, code 0 is reserved for compact headers.
+func RegisterPacketType(code byte, name string, factory PacketFactory) error {
+	if factory == nil {
+		return fmt.Errorf("nil factory for packet type 0x%x", code)
+	}
+	if code == compactHeaderMarker {
+		return fmt.Errorf("packet type 0x%x is reserved for compact headers", code)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if pt, ok := registry.types[code]; ok {
+		return fmt.Errorf("%w: 0x%x already registered as %s", ErrDuplicatePacketType, code, pt.name)
+	}
+	registry.types[code] = packetType{name: name, factory: factory}
+	return nil
+}
+
+func mustRegisterPacketType(code byte, name string, factory PacketFactory) {
+	if err := RegisterPacketType(code, name, factory); err != nil {
+		panic(err)
+	}
+}
+
+//PacketName returns the registered name of the packet type code, or an
+//empty string if the code is unknown.
+func PacketName(code byte) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.types[code].name
+}
+
+func lookupPacketType(code byte) (packetType, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	pt, ok := registry.types[code]
+	return pt, ok
+}
@@ -0,0 +1,88 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+const testPacketType = 0xF0
+
+type testPacket struct {
+	PingreqPacket
+}
+
+func init() {
+	mustRegisterPacketType(testPacketType, "TESTPACKET", func(fh FixedHeader) ControlPacket {
+		return &testPacket{PingreqPacket{FixedHeader: fh}}
+	})
+}
+
+func TestRegisterPacketTypeDuplicate(t *testing.T) {
+	err := RegisterPacketType(Pingreq, "PINGREQ2", func(fh FixedHeader) ControlPacket {
+		return &PingreqPacket{FixedHeader: fh}
+	})
+	if !errors.Is(err, ErrDuplicatePacketType) {
+		t.Errorf("RegisterPacketType(Pingreq) returned %v, should be %v", err, ErrDuplicatePacketType)
+	}
+	if PacketName(Pingreq) != "PINGREQ" {
+		t.Errorf("PacketName(Pingreq) is %s after duplicate registration, should be %s", PacketName(Pingreq), "PINGREQ")
+	}
+}
+
+func TestRegisteredPacketType(t *testing.T) {
+	if PacketName(testPacketType) != "TESTPACKET" {
+		t.Errorf("PacketName(0x%x) is %s, should be %s", testPacketType, PacketName(testPacketType), "TESTPACKET")
+	}
+	packet, ok := NewControlPacket(testPacketType).(*testPacket)
+	if !ok {
+		t.Fatalf("NewControlPacket(0x%x) did not return a *testPacket", testPacketType)
+	}
+	if packet.MessageType != testPacketType {
+		t.Errorf("MessageType is 0x%x, should be 0x%x", packet.MessageType, testPacketType)
+	}
+	packet.MsqSeq = 7
+	buf := new(bytes.Buffer)
+	if err := packet.Write(buf); err != nil {
+		t.Fatalf("Write of %T returned error: %s", packet, err)
+	}
+	read, err := ReadPacket(buf)
+	if err != nil {
+		t.Fatalf("Read of packed %T returned error: %s", packet, err)
+	}
+	if _, ok := read.(*testPacket); !ok {
+		t.Errorf("ReadPacket returned %T, should be *testPacket", read)
+	}
+	if read.String() != packet.String() {
+		t.Errorf("Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", packet, packet, read)
+	}
+}
+
+func TestUnregisteredPacketType(t *testing.T) {
+	if cp := NewControlPacket(0xEF); cp != nil {
+		t.Errorf("NewControlPacket(0xEF) returned %T, should be nil", cp)
+	}
+	if _, err := NewControlPacketWithHeader(FixedHeader{MessageType: 0xEF}); err == nil {
+		t.Errorf("NewControlPacketWithHeader(0xEF) did not return an error")
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(code byte) {
+			defer wg.Done()
+			RegisterPacketType(code, "CONCURRENT", func(fh FixedHeader) ControlPacket {
+				return &PingreqPacket{FixedHeader: fh}
+			})
+		}(byte(0xE0 + i))
+		go func() {
+			defer wg.Done()
+			NewControlPacket(Pingreq)
+			PacketName(Loginreq)
+		}()
+	}
+	wg.Wait()
+}
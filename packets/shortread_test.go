@@ -0,0 +1,102 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+//shortReadPackets returns a stream of packets of every built-in type
+func shortReadPackets() []ControlPacket {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.UserId = "user"
+	login.Token = "token"
+	jsonLogin := NewControlPacket(Loginreq).(*LoginreqPacket)
+	jsonLogin.Format = FormatJson
+	jsonLogin.UserId = "json"
+	peerMsg := NewControlPacket(Peermsgsendreq).(*PeermsgsendreqPacket)
+	peerMsg.Sender = "alice"
+	peerMsg.Receiver = "bob"
+	peerMsg.Body = &protocol.PeerMsgSendReq_Text{Text: &protocol.MessageText{Content: "hello"}}
+	return []ControlPacket{
+		NewControlPacket(Pingreq),
+		login,
+		jsonLogin,
+		NewKickoutreqPacket(protocol.KickoutReq_OTHER_DEVICE_LOGIN),
+		NewControlPacket(Logoutreq),
+		peerMsg,
+		NewControlPacket(Pingresp),
+		NewControlPacket(Disconnect),
+	}
+}
+
+func TestReadPacketShortReads(t *testing.T) {
+	readers := map[string]func(io.Reader) io.Reader{
+		"OneByteReader": iotest.OneByteReader,
+		"HalfReader":    iotest.HalfReader,
+		"DataErrReader": iotest.DataErrReader,
+	}
+	written := shortReadPackets()
+	stream := new(bytes.Buffer)
+	for _, packet := range written {
+		if err := packet.Write(stream); err != nil {
+			t.Fatalf("Write of %T returned error: %s", packet, err)
+		}
+	}
+	for name, reader := range readers {
+		r := reader(bytes.NewReader(stream.Bytes()))
+		for _, packet := range written {
+			read, err := ReadPacket(r)
+			if err != nil {
+				t.Fatalf("%s: Read of packed %T returned error: %s", name, packet, err)
+			}
+			if read.String() != packet.String() {
+				t.Errorf("%s: Read of packed %T did not equal original.\nExpected: %v\n     Got: %v", name, packet, packet, read)
+			}
+		}
+		if _, err := ReadPacket(r); err != io.EOF {
+			t.Errorf("%s: ReadPacket at end of stream returned %v, should be %v", name, err, io.EOF)
+		}
+	}
+}
+
+func TestReadPacketTruncated(t *testing.T) {
+	for _, packet := range shortReadPackets() {
+		frame := new(bytes.Buffer)
+		if err := packet.Write(frame); err != nil {
+			t.Fatalf("Write of %T returned error: %s", packet, err)
+		}
+		for n := 1; n < frame.Len(); n++ {
+			r := iotest.OneByteReader(bytes.NewReader(frame.Bytes()[:n]))
+			if _, err := ReadPacket(r); !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, ErrNetwork) {
+				t.Errorf("ReadPacket of %T truncated to %d bytes returned %v, should be %v", packet, n, err, io.ErrUnexpectedEOF)
+			}
+		}
+	}
+}
+
+func TestDecodeHelpersShortReads(t *testing.T) {
+	r := iotest.OneByteReader(bytes.NewReader([]byte{0x56, 0x56, 0x78, 0x00, 0x80, 0x80, 0x01, 0x00, 0x03, 'f', 'o', 'o'}))
+	if res, err := decodeByte(r); res != 0x56 || err != nil {
+		t.Errorf("decodeByte did not return (0x56, nil) but (0x%X, %v)", res, err)
+	}
+	if res, err := decodeUint16(r); res != 22136 || err != nil {
+		t.Errorf("decodeUint16 did not return (22136, nil) but (%d, %v)", res, err)
+	}
+	if res, err := decodeUint32(r); res != 8421377 || err != nil {
+		t.Errorf("decodeUint32 did not return (8421377, nil) but (%d, %v)", res, err)
+	}
+	if res, err := decodeString(r); res != "foo" || err != nil {
+		t.Errorf("decodeString did not return (\"foo\", nil) but (%q, %v)", res, err)
+	}
+
+	if _, err := decodeUint32(bytes.NewReader([]byte{0x00, 0x01})); err != io.ErrUnexpectedEOF {
+		t.Errorf("decodeUint32 of 2 bytes returned %v, should be %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := decodeString(bytes.NewReader([]byte{0x00, 0x03, 'f'})); err != io.ErrUnexpectedEOF {
+		t.Errorf("decodeString of a truncated field returned %v, should be %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,53 @@
+package packets
+
+import (
+	"io"
+)
+
+//SplitPacket is a bufio.SplitFunc that splits a stream into whole frames,
+//each token holding the header in either layout followed by its payload
+//and, with FlagChecksum set, its CRC32 trailer, without decoding them.
+//Frames announcing more than MAX_PAYLOAD_LENGTH_3MB stop the scan with a
+//PayloadLengthError, frames not matching their trailer with a
+//ChecksumError and a frame cut short by the end of the stream with
+//io.ErrUnexpectedEOF.
+//
+//The default buffer of a bufio.Scanner only holds frames up to 64KB, allow
+//for the largest frame with
+//
+//	scanner.Buffer(nil, MAX_PAYLOAD_LENGTH_3MB+18)
+func SplitPacket(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	fh, headerLength, err := unpackHeader(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if headerLength == 0 {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	if fh.RemainingLength > MAX_PAYLOAD_LENGTH_3MB {
+		return 0, nil, &PayloadLengthError{MessageType: fh.MessageType, Length: fh.RemainingLength, Limit: MAX_PAYLOAD_LENGTH_3MB}
+	}
+	payloadEnd := headerLength + int(fh.RemainingLength)
+	frameLength := payloadEnd
+	if fh.Flag&FlagChecksum != 0 {
+		frameLength += checksumTrailerLength
+	}
+	if len(data) < frameLength {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	if fh.Flag&FlagChecksum != 0 {
+		if err := verifyChecksum(fh, data[:headerLength], data[headerLength:payloadEnd], data[payloadEnd:frameLength]); err != nil {
+			return 0, nil, err
+		}
+	}
+	return frameLength, data[:frameLength], nil
+}
@@ -0,0 +1,63 @@
+package packets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplitPacket(t *testing.T) {
+	written := shortReadPackets()
+	stream := new(bytes.Buffer)
+	for _, packet := range written {
+		if err := packet.Write(stream); err != nil {
+			t.Fatalf("Write of %T returned error: %s", packet, err)
+		}
+	}
+	scanner := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(stream.Bytes())))
+	scanner.Split(SplitPacket)
+	var i int
+	for ; scanner.Scan(); i++ {
+		if i >= len(written) {
+			t.Fatalf("SplitPacket returned more than %d frames", len(written))
+		}
+		frame := new(bytes.Buffer)
+		written[i].Write(frame)
+		if !bytes.Equal(scanner.Bytes(), frame.Bytes()) {
+			t.Errorf("Frame %d is [0x%X], should be [0x%X]", i, scanner.Bytes(), frame.Bytes())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Errorf("Scan returned error: %s", err)
+	}
+	if i != len(written) {
+		t.Errorf("SplitPacket returned %d frames, should return %d", i, len(written))
+	}
+}
+
+func TestSplitPacketErrors(t *testing.T) {
+	frame := new(bytes.Buffer)
+	NewControlPacket(Logoutreq).(*LogoutreqPacket).Write(frame)
+	NewControlPacket(Pingreq).Write(frame)
+	truncated := frame.Bytes()[:frame.Len()-1]
+
+	scanner := bufio.NewScanner(bytes.NewReader(truncated))
+	scanner.Split(SplitPacket)
+	if !scanner.Scan() {
+		t.Fatalf("Scan of the first frame returned error: %s", scanner.Err())
+	}
+	if scanner.Scan() || scanner.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Scan of a truncated frame returned %v, should be %v", scanner.Err(), io.ErrUnexpectedEOF)
+	}
+
+	header := FixedHeader{MessageType: Pingreq, RemainingLength: MAX_PAYLOAD_LENGTH_3MB + 1}
+	oversized := header.pack()
+	scanner = bufio.NewScanner(&oversized)
+	scanner.Split(SplitPacket)
+	if scanner.Scan() || !errors.Is(scanner.Err(), ErrOutMaxPayloadLength) {
+		t.Errorf("Scan of an oversized frame returned %v, should be %v", scanner.Err(), ErrOutMaxPayloadLength)
+	}
+}
@@ -0,0 +1,72 @@
+package packets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//flagDefined holds every Flag bit with a meaning, the other bits are
+//reserved
+const flagDefined = FlagCompressionMask | FlagEncrypted | FlagChecksum | FlagFragment
+
+//Below are the errors wrapped by the ProtocolErrors a strict Decoder
+//returns, see Decoder.SetStrict
+var (
+	ErrUnknownFormat = errors.New("unknown payload format")
+	ErrReservedFlag  = errors.New("reserved flag bits set")
+	ErrTrailingData  = errors.New("trailing data after payload")
+)
+
+//checkStrictHeader rejects a header whose Format has no Codec or whose
+//Flag has reserved bits set
+func checkStrictHeader(fh FixedHeader) error {
+	if !hasCodec(fh.Format) {
+		return fmt.Errorf("%w %d", ErrUnknownFormat, fh.Format)
+	}
+	if reserved := fh.Flag &^ flagDefined; reserved != 0 {
+		return fmt.Errorf("%w 0x%x", ErrReservedFlag, reserved)
+	}
+	return nil
+}
+
+//checkStrictPayload rejects cp if its Unpack left n bytes of the payload
+//unread, as packets without a payload leave all of it, or if its proto
+//message holds fields unknown to it
+func checkStrictPayload(cp ControlPacket, n int) error {
+	if n > 0 {
+		return fmt.Errorf("%w: %d bytes left undecoded", ErrTrailingData, n)
+	}
+	if pp, ok := cp.(protoPacket); ok && hasUnknownFields(proto.MessageReflect(pp.message())) {
+		return fmt.Errorf("%w: unknown fields in %s", ErrTrailingData, proto.MessageReflect(pp.message()).Descriptor().FullName())
+	}
+	return nil
+}
+
+//hasUnknownFields reports whether m or any message nested in it holds
+//unknown fields
+func hasUnknownFields(m protoreflect.Message) bool {
+	if len(m.GetUnknown()) > 0 {
+		return true
+	}
+	unknown := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len() && !unknown; i++ {
+				unknown = hasUnknownFields(l.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				unknown = hasUnknownFields(v.Message())
+				return !unknown
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			unknown = hasUnknownFields(v.Message())
+		}
+		return !unknown
+	})
+	return unknown
+}
@@ -0,0 +1,71 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecoderStrict(t *testing.T) {
+	login := NewControlPacket(Loginreq).(*LoginreqPacket)
+	login.MsqSeq = 9
+	login.UserId = "user"
+	valid := new(bytes.Buffer)
+	login.Write(valid)
+
+	trailing := append(append([]byte(nil), valid.Bytes()...), 0x78, 0x01)
+	trailing[11] += 2
+	ping := FixedHeader{MessageType: Pingreq, MsqSeq: 9, RemainingLength: 3}
+	format := append([]byte(nil), valid.Bytes()...)
+	format[6] = 7
+	flag := FixedHeader{MessageType: Pingreq, MsqSeq: 9, Flag: 0x80}
+
+	tests := []struct {
+		name  string
+		frame []byte
+		inner error
+	}{
+		{"trailing proto data", trailing, ErrTrailingData},
+		{"payload on pingreq", append(ping.appendTo(nil), 1, 2, 3), ErrTrailingData},
+		{"unknown format", format, ErrUnknownFormat},
+		{"reserved flag", flag.appendTo(nil), ErrReservedFlag},
+	}
+	for _, test := range tests {
+		if _, err := NewDecoder(bytes.NewReader(test.frame)).Decode(); err != nil {
+			t.Errorf("%s: Decode without strict mode returned error: %s", test.name, err)
+		}
+
+		d := NewDecoder(bytes.NewReader(append(append([]byte(nil), test.frame...), valid.Bytes()...)))
+		d.SetStrict(true)
+		cp, err := d.Decode()
+		if cp != nil {
+			t.Errorf("%s: strict Decode returned %v, should return nil", test.name, cp)
+		}
+		var pe *ProtocolError
+		if !errors.As(err, &pe) || pe.Code != ErrProtocolViolation || !errors.Is(err, test.inner) {
+			t.Errorf("%s: strict Decode returned %v, should return a protocol violation wrapping %v", test.name, err, test.inner)
+			continue
+		}
+		if pe.MsqSeq != 9 {
+			t.Errorf("%s: ProtocolError msgSeq is %d, should be 9", test.name, pe.MsqSeq)
+		}
+		if read, err := d.Decode(); err != nil || read.String() != login.String() {
+			t.Errorf("%s: Decode of the next frame returned %v, %v, should return %v", test.name, read, err, login)
+		}
+	}
+
+	for _, packet := range shortReadPackets() {
+		b := new(bytes.Buffer)
+		e := NewEncoder(b)
+		e.SetChecksum(true)
+		e.SetCompression(FlagCompressGzip, 0)
+		if err := e.Encode(packet); err != nil {
+			t.Fatalf("Encode of %T returned error: %s", packet, err)
+		}
+		d := NewDecoder(b)
+		d.SetStrict(true)
+		if _, err := d.Decode(); err != nil {
+			t.Errorf("Strict Decode of %T returned error: %s", packet, err)
+		}
+	}
+}
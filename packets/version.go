@@ -0,0 +1,104 @@
+package packets
+
+import (
+	"fmt"
+	"sync"
+)
+
+//CurrentVersion is the protocol version whose header and payload layouts
+//the packets of this package are written in. Frames of other versions,
+//typically from peers on older releases, are converted to it by the
+//upgrades registered with RegisterVersionUpgrade.
+const CurrentVersion = 0
+
+//VersionError is returned for a frame whose Version lies outside the range
+//accepted by the Decoder, or by NegotiateVersion for a peer that cannot be
+//served. It matches ErrBadProtocolVersion, and ReadPacket and Decoder
+//return it wrapped in a ProtocolError with that code.
+type VersionError struct {
+	MessageType byte
+	MsqSeq      uint32
+	Version     byte
+	Min         byte
+	Max         byte
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("tcp protocol package %s msgSeq:%d %s %d, accepted are %d to %d", PacketName(e.MessageType), e.MsqSeq, ErrBadProtocolVersion.Message, e.Version, e.Min, e.Max)
+}
+
+//Is reports ErrBadProtocolVersion as matching
+func (e *VersionError) Is(target error) bool {
+	return target == ErrBadProtocolVersion
+}
+
+//NegotiateVersion picks the version of a session from the Version of the
+//peer's LoginreqPacket and the range of versions supported locally. A peer
+//newer than max is answered in max, a peer older than min is refused with
+//a VersionError. The result is meant for Encoder.SetVersion, the Decoder
+//keeps accepting the whole range.
+func NegotiateVersion(req *LoginreqPacket, min, max byte) (byte, error) {
+	switch {
+	case req.Version < min:
+		return 0, &VersionError{MessageType: req.MessageType, MsqSeq: req.MsqSeq, Version: req.Version, Min: min, Max: max}
+	case req.Version > max:
+		return max, nil
+	default:
+		return req.Version, nil
+	}
+}
+
+//VersionUpgrade converts a frame of another protocol version, given as its
+//header and payload after decryption and decompression, into the layout
+//of CurrentVersion. The returned header may differ in any field but
+//RemainingLength, which is set from the returned payload.
+type VersionUpgrade func(fh FixedHeader, payload []byte) (FixedHeader, []byte, error)
+
+type versionUpgradeKey struct {
+	version    byte
+	packetType byte
+}
+
+//versionUpgrades holds the upgrades registered with RegisterVersionUpgrade
+var versionUpgrades = struct {
+	sync.RWMutex
+	upgrades map[versionUpgradeKey]VersionUpgrade
+}{upgrades: make(map[versionUpgradeKey]VersionUpgrade)}
+
+//RegisterVersionUpgrade makes ReadPacket and Decoder pass packets of
+//packetType written in version through upgrade before decoding them.
+//Packets of versions without an upgrade are decoded as they are.
+//Registering an upgrade twice returns an error.
+func RegisterVersionUpgrade(version, packetType byte, upgrade VersionUpgrade) error {
+	if upgrade == nil {
+		return fmt.Errorf("nil upgrade for version %d of packet type 0x%x", version, packetType)
+	}
+	versionUpgrades.Lock()
+	defer versionUpgrades.Unlock()
+	key := versionUpgradeKey{version: version, packetType: packetType}
+	if _, ok := versionUpgrades.upgrades[key]; ok {
+		return fmt.Errorf("upgrade for version %d of packet type 0x%x already registered", version, packetType)
+	}
+	versionUpgrades.upgrades[key] = upgrade
+	return nil
+}
+
+//upgradeVersion passes the frame with header fh and payload through the
+//upgrade registered for its version and type, if any
+func upgradeVersion(fh FixedHeader, payload []byte) (FixedHeader, []byte, error) {
+	if fh.Version == CurrentVersion {
+		return fh, payload, nil
+	}
+	versionUpgrades.RLock()
+	upgrade, ok := versionUpgrades.upgrades[versionUpgradeKey{version: fh.Version, packetType: fh.MessageType}]
+	versionUpgrades.RUnlock()
+	if !ok {
+		return fh, payload, nil
+	}
+	fh, payload, err := upgrade(fh, payload)
+	if err != nil {
+		return fh, nil, err
+	}
+	fh.RemainingLength = uint32(len(payload))
+	return fh, payload, nil
+}
@@ -0,0 +1,123 @@
+package packets
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bitstreamstudio/im-packets/protocol"
+	"github.com/golang/protobuf/proto"
+	"testing"
+)
+
+//testLegacyVersion is a made-up older version whose KickoutreqPacket
+//payload was the reason as a single byte
+const testLegacyVersion = 0xF0
+
+func init() {
+	err := RegisterVersionUpgrade(testLegacyVersion, Kickoutreq, func(fh FixedHeader, payload []byte) (FixedHeader, []byte, error) {
+		if len(payload) != 1 {
+			return fh, nil, errors.New("legacy kickout payload is not a single byte")
+		}
+		b, err := proto.Marshal(&protocol.KickoutReq{Reason: protocol.KickoutReq_Reason(payload[0])})
+		return fh, b, err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestDecoderVersionRange(t *testing.T) {
+	stream := new(bytes.Buffer)
+	for _, version := range []byte{1, 2, 3} {
+		logout := NewControlPacket(Logoutreq).(*LogoutreqPacket)
+		logout.Version = version
+		logout.MsqSeq = uint32(version)
+		logout.Write(stream)
+	}
+
+	d := NewDecoder(stream)
+	d.SetVersionRange(1, 2)
+	for _, version := range []byte{1, 2} {
+		cp, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode of version %d returned error: %s", version, err)
+		}
+		if cp.(*LogoutreqPacket).Version != version {
+			t.Errorf("Version of the decoded packet is %d, should be %d", cp.(*LogoutreqPacket).Version, version)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		_, err := d.Decode()
+		var versionErr *VersionError
+		if !errors.As(err, &versionErr) || !errors.Is(err, ConnErrors[ErrRefusedBadProtocolVersion]) {
+			t.Fatalf("Decode %d of version 3 returned %v, should return a VersionError", i, err)
+		}
+		if versionErr.Version != 3 || versionErr.MsqSeq != 3 {
+			t.Errorf("VersionError is for version %d msgSeq:%d, should be for version 3 msgSeq:3", versionErr.Version, versionErr.MsqSeq)
+		}
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		peer    byte
+		version byte
+		err     bool
+	}{
+		{0, 0, true},
+		{1, 1, false},
+		{2, 2, false},
+		{5, 3, false},
+	}
+	for _, test := range tests {
+		req := NewControlPacket(Loginreq).(*LoginreqPacket)
+		req.Version = test.peer
+		version, err := NegotiateVersion(req, 1, 3)
+		if test.err {
+			if !errors.Is(err, ConnErrors[ErrRefusedBadProtocolVersion]) {
+				t.Errorf("NegotiateVersion with peer version %d returned %v, should return a VersionError", test.peer, err)
+			}
+			continue
+		}
+		if err != nil || version != test.version {
+			t.Errorf("NegotiateVersion with peer version %d returned %d, %v, should return %d", test.peer, version, err, test.version)
+		}
+	}
+}
+
+func TestEncoderVersion(t *testing.T) {
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	e.SetVersion(3)
+	if err := e.Encode(NewControlPacket(Logoutreq)); err != nil {
+		t.Fatalf("Encode of LogoutreqPacket returned error: %s", err)
+	}
+	if b.Bytes()[5] != 3 {
+		t.Errorf("Version of the encoded frame is %d, should be 3", b.Bytes()[5])
+	}
+}
+
+func TestVersionUpgrade(t *testing.T) {
+	legacy := &RawPacket{
+		FixedHeader: FixedHeader{MessageType: Kickoutreq, MsqSeq: 4, Version: testLegacyVersion},
+		Payload:     []byte{byte(protocol.KickoutReq_OTHER_DEVICE_LOGIN)},
+	}
+	b := new(bytes.Buffer)
+	legacy.Write(b)
+	read, err := ReadPacket(b)
+	if err != nil {
+		t.Fatalf("ReadPacket of a legacy KickoutreqPacket returned error: %s", err)
+	}
+	kickout, ok := read.(*KickoutreqPacket)
+	if !ok {
+		t.Fatalf("ReadPacket returned %T, should be *KickoutreqPacket", read)
+	}
+	if kickout.Reason != protocol.KickoutReq_OTHER_DEVICE_LOGIN || kickout.Version != testLegacyVersion || kickout.MsqSeq != 4 {
+		t.Errorf("Upgraded packet is %v, should carry reason %v", kickout, protocol.KickoutReq_OTHER_DEVICE_LOGIN)
+	}
+
+	if err := RegisterVersionUpgrade(testLegacyVersion, Kickoutreq, func(fh FixedHeader, payload []byte) (FixedHeader, []byte, error) {
+		return fh, payload, nil
+	}); err == nil {
+		t.Errorf("RegisterVersionUpgrade of a registered upgrade returned no error")
+	}
+}
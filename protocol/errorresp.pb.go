@@ -0,0 +1,174 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.13.0
+// source: errorresp.proto
+
+package protocol
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// Sent by the server to reject a single request while keeping the
+// connection open, its FixedHeader carries the MsqSeq of the rejected
+// request, see packets.NewErrorResponse.
+type ErrorResp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	//被拒绝请求的报文类型
+	RequestType uint32 `protobuf:"varint,1,opt,name=request_type,json=requestType,proto3" json:"request_type,omitempty"`
+	//错误码,取值同packets中的错误码常量
+	Code uint32 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	//可读的错误原因
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *ErrorResp) Reset() {
+	*x = ErrorResp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_errorresp_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorResp) ProtoMessage() {}
+
+func (x *ErrorResp) ProtoReflect() protoreflect.Message {
+	mi := &file_errorresp_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorResp.ProtoReflect.Descriptor instead.
+func (*ErrorResp) Descriptor() ([]byte, []int) {
+	return file_errorresp_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ErrorResp) GetRequestType() uint32 {
+	if x != nil {
+		return x.RequestType
+	}
+	return 0
+}
+
+func (x *ErrorResp) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ErrorResp) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+var File_errorresp_proto protoreflect.FileDescriptor
+
+var file_errorresp_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x72, 0x65, 0x73, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x0f, 0x69, 0x6d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0x60, 0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x12,
+	0x21, 0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x3a, 0x04,
+	0xa0, 0xbb, 0x18, 0x0a, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_errorresp_proto_rawDescOnce sync.Once
+	file_errorresp_proto_rawDescData = file_errorresp_proto_rawDesc
+)
+
+func file_errorresp_proto_rawDescGZIP() []byte {
+	file_errorresp_proto_rawDescOnce.Do(func() {
+		file_errorresp_proto_rawDescData = protoimpl.X.CompressGZIP(file_errorresp_proto_rawDescData)
+	})
+	return file_errorresp_proto_rawDescData
+}
+
+var file_errorresp_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_errorresp_proto_goTypes = []interface{}{
+	(*ErrorResp)(nil), // 0: ErrorResp
+}
+var file_errorresp_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_errorresp_proto_init() }
+func file_errorresp_proto_init() {
+	if File_errorresp_proto != nil {
+		return
+	}
+	file_impackets_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_errorresp_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ErrorResp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_errorresp_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_errorresp_proto_goTypes,
+		DependencyIndexes: file_errorresp_proto_depIdxs,
+		MessageInfos:      file_errorresp_proto_msgTypes,
+	}.Build()
+	File_errorresp_proto = out.File
+	file_errorresp_proto_rawDesc = nil
+	file_errorresp_proto_goTypes = nil
+	file_errorresp_proto_depIdxs = nil
+}
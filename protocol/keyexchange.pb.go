@@ -0,0 +1,222 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.13.0
+// source: keyexchange.proto
+
+package protocol
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type KeyExchange_Curve int32
+
+const (
+	KeyExchange_X25519 KeyExchange_Curve = 0
+	KeyExchange_P256   KeyExchange_Curve = 1
+	KeyExchange_P384   KeyExchange_Curve = 2
+	KeyExchange_P521   KeyExchange_Curve = 3
+)
+
+// Enum value maps for KeyExchange_Curve.
+var (
+	KeyExchange_Curve_name = map[int32]string{
+		0: "X25519",
+		1: "P256",
+		2: "P384",
+		3: "P521",
+	}
+	KeyExchange_Curve_value = map[string]int32{
+		"X25519": 0,
+		"P256":   1,
+		"P384":   2,
+		"P521":   3,
+	}
+)
+
+func (x KeyExchange_Curve) Enum() *KeyExchange_Curve {
+	p := new(KeyExchange_Curve)
+	*p = x
+	return p
+}
+
+func (x KeyExchange_Curve) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeyExchange_Curve) Descriptor() protoreflect.EnumDescriptor {
+	return file_keyexchange_proto_enumTypes[0].Descriptor()
+}
+
+func (KeyExchange_Curve) Type() protoreflect.EnumType {
+	return &file_keyexchange_proto_enumTypes[0]
+}
+
+func (x KeyExchange_Curve) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeyExchange_Curve.Descriptor instead.
+func (KeyExchange_Curve) EnumDescriptor() ([]byte, []int) {
+	return file_keyexchange_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Sent by both sides right after the LoginreqPacket to agree on the key
+// encrypting the payloads of the session, see packets.KeyExchange.
+type KeyExchange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Curve KeyExchange_Curve `protobuf:"varint,1,opt,name=curve,proto3,enum=KeyExchange_Curve" json:"curve,omitempty"`
+	//ECDH公钥
+	PublicKey []byte `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+}
+
+func (x *KeyExchange) Reset() {
+	*x = KeyExchange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keyexchange_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyExchange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyExchange) ProtoMessage() {}
+
+func (x *KeyExchange) ProtoReflect() protoreflect.Message {
+	mi := &file_keyexchange_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyExchange.ProtoReflect.Descriptor instead.
+func (*KeyExchange) Descriptor() ([]byte, []int) {
+	return file_keyexchange_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KeyExchange) GetCurve() KeyExchange_Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return KeyExchange_X25519
+}
+
+func (x *KeyExchange) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+var File_keyexchange_proto protoreflect.FileDescriptor
+
+var file_keyexchange_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x6b, 0x65, 0x79, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x0f, 0x69, 0x6d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x8f, 0x01, 0x0a, 0x0b, 0x4b, 0x65, 0x79, 0x45, 0x78, 0x63, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x4b, 0x65, 0x79, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x2e, 0x43, 0x75, 0x72, 0x76, 0x65, 0x52, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x22, 0x31, 0x0a,
+	0x05, 0x43, 0x75, 0x72, 0x76, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x58, 0x32, 0x35, 0x35, 0x31, 0x39,
+	0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x32, 0x35, 0x36, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04,
+	0x50, 0x33, 0x38, 0x34, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x35, 0x32, 0x31, 0x10, 0x03,
+	0x3a, 0x04, 0xa0, 0xbb, 0x18, 0x09, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_keyexchange_proto_rawDescOnce sync.Once
+	file_keyexchange_proto_rawDescData = file_keyexchange_proto_rawDesc
+)
+
+func file_keyexchange_proto_rawDescGZIP() []byte {
+	file_keyexchange_proto_rawDescOnce.Do(func() {
+		file_keyexchange_proto_rawDescData = protoimpl.X.CompressGZIP(file_keyexchange_proto_rawDescData)
+	})
+	return file_keyexchange_proto_rawDescData
+}
+
+var file_keyexchange_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_keyexchange_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_keyexchange_proto_goTypes = []interface{}{
+	(KeyExchange_Curve)(0), // 0: KeyExchange.Curve
+	(*KeyExchange)(nil),    // 1: KeyExchange
+}
+var file_keyexchange_proto_depIdxs = []int32{
+	0, // 0: KeyExchange.curve:type_name -> KeyExchange.Curve
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_keyexchange_proto_init() }
+func file_keyexchange_proto_init() {
+	if File_keyexchange_proto != nil {
+		return
+	}
+	file_impackets_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_keyexchange_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyExchange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_keyexchange_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_keyexchange_proto_goTypes,
+		DependencyIndexes: file_keyexchange_proto_depIdxs,
+		EnumInfos:         file_keyexchange_proto_enumTypes,
+		MessageInfos:      file_keyexchange_proto_msgTypes,
+	}.Build()
+	File_keyexchange_proto = out.File
+	file_keyexchange_proto_rawDesc = nil
+	file_keyexchange_proto_goTypes = nil
+	file_keyexchange_proto_depIdxs = nil
+}
@@ -25,6 +25,9 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+// Unlike DisconnectPacket, which only closes the transport, it ends the
+// login session and the server is expected to invalidate the token that
+// was used in the LoginreqPacket.
 type LogoutReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -67,9 +70,10 @@ var File_logoutreq_proto protoreflect.FileDescriptor
 
 var file_logoutreq_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x72, 0x65, 0x71, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x22, 0x0b, 0x0a, 0x09, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x42, 0x0c,
-	0x5a, 0x0a, 0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x1a, 0x0f, 0x69, 0x6d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0x11, 0x0a, 0x09, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x3a,
+	0x04, 0xa0, 0xbb, 0x18, 0x07, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -101,6 +105,7 @@ func file_logoutreq_proto_init() {
 	if File_logoutreq_proto != nil {
 		return
 	}
+	file_impackets_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_logoutreq_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LogoutReq); i {
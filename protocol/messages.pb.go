@@ -25,6 +25,58 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+type MessageState int32
+
+const (
+	MessageState_accepted   MessageState = 0
+	MessageState_dispatched MessageState = 1
+	MessageState_received   MessageState = 2
+	MessageState_cousumed   MessageState = 3
+)
+
+// Enum value maps for MessageState.
+var (
+	MessageState_name = map[int32]string{
+		0: "accepted",
+		1: "dispatched",
+		2: "received",
+		3: "cousumed",
+	}
+	MessageState_value = map[string]int32{
+		"accepted":   0,
+		"dispatched": 1,
+		"received":   2,
+		"cousumed":   3,
+	}
+)
+
+func (x MessageState) Enum() *MessageState {
+	p := new(MessageState)
+	*p = x
+	return p
+}
+
+func (x MessageState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageState) Descriptor() protoreflect.EnumDescriptor {
+	return file_messages_proto_enumTypes[0].Descriptor()
+}
+
+func (MessageState) Type() protoreflect.EnumType {
+	return &file_messages_proto_enumTypes[0]
+}
+
+func (x MessageState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageState.Descriptor instead.
+func (MessageState) EnumDescriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{0}
+}
+
 type MessageText_Type int32
 
 const (
@@ -57,11 +109,11 @@ func (x MessageText_Type) String() string {
 }
 
 func (MessageText_Type) Descriptor() protoreflect.EnumDescriptor {
-	return file_messages_proto_enumTypes[0].Descriptor()
+	return file_messages_proto_enumTypes[1].Descriptor()
 }
 
 func (MessageText_Type) Type() protoreflect.EnumType {
-	return &file_messages_proto_enumTypes[0]
+	return &file_messages_proto_enumTypes[1]
 }
 
 func (x MessageText_Type) Number() protoreflect.EnumNumber {
@@ -398,6 +450,7 @@ type MessageLocation struct {
 
 	Latitude  float32 `protobuf:"fixed32,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
 	Longitude float32 `protobuf:"fixed32,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Address   string  `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
 }
 
 func (x *MessageLocation) Reset() {
@@ -446,6 +499,13 @@ func (x *MessageLocation) GetLongitude() float32 {
 	return 0
 }
 
+func (x *MessageLocation) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
 var File_messages_proto protoreflect.FileDescriptor
 
 var file_messages_proto_rawDesc = []byte{
@@ -480,13 +540,20 @@ var file_messages_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x72, 0x63, 0x55, 0x72, 0x6c, 0x12, 0x19, 0x0a, 0x08,
 	0x73, 0x72, 0x63, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
 	0x73, 0x72, 0x63, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x22, 0x4b, 0x0a, 0x0f, 0x4d,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x22, 0x65, 0x0a, 0x0f, 0x4d,
 	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a,
 	0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02,
 	0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f,
 	0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x6c,
-	0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x2a, 0x48, 0x0a, 0x0c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x10, 0x00,
+	0x12, 0x0e, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x10, 0x01,
+	0x12, 0x0c, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x10, 0x02, 0x12, 0x0c,
+	0x0a, 0x08, 0x63, 0x6f, 0x75, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x10, 0x03, 0x42, 0x0c, 0x5a, 0x0a,
+	0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -501,19 +568,20 @@ func file_messages_proto_rawDescGZIP() []byte {
 	return file_messages_proto_rawDescData
 }
 
-var file_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
 var file_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_messages_proto_goTypes = []interface{}{
-	(MessageText_Type)(0),   // 0: MessageText.Type
-	(*MessageText)(nil),     // 1: MessageText
-	(*MessageImage)(nil),    // 2: MessageImage
-	(*MessageAudio)(nil),    // 3: MessageAudio
-	(*MessageVideo)(nil),    // 4: MessageVideo
-	(*MessageFile)(nil),     // 5: MessageFile
-	(*MessageLocation)(nil), // 6: MessageLocation
+	(MessageState)(0),       // 0: MessageState
+	(MessageText_Type)(0),   // 1: MessageText.Type
+	(*MessageText)(nil),     // 2: MessageText
+	(*MessageImage)(nil),    // 3: MessageImage
+	(*MessageAudio)(nil),    // 4: MessageAudio
+	(*MessageVideo)(nil),    // 5: MessageVideo
+	(*MessageFile)(nil),     // 6: MessageFile
+	(*MessageLocation)(nil), // 7: MessageLocation
 }
 var file_messages_proto_depIdxs = []int32{
-	0, // 0: MessageText.type:type_name -> MessageText.Type
+	1, // 0: MessageText.type:type_name -> MessageText.Type
 	1, // [1:1] is the sub-list for method output_type
 	1, // [1:1] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
@@ -605,7 +673,7 @@ func file_messages_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_messages_proto_rawDesc,
-			NumEnums:      1,
+			NumEnums:      2,
 			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
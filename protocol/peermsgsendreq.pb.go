@@ -25,6 +25,8 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+// A one-to-one chat message whose body is one of the message types from
+// messages.proto.
 type PeerMsgSendReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -32,6 +34,18 @@ type PeerMsgSendReq struct {
 
 	Sender   string `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
 	Receiver string `protobuf:"bytes,2,opt,name=receiver,proto3" json:"receiver,omitempty"`
+	//客户端生成的消息id,用于去重和回执
+	ClientMsgId string `protobuf:"bytes,3,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	//客户端发送时间,毫秒时间戳
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are assignable to Body:
+	//	*PeerMsgSendReq_Text
+	//	*PeerMsgSendReq_Image
+	//	*PeerMsgSendReq_Audio
+	//	*PeerMsgSendReq_Video
+	//	*PeerMsgSendReq_File
+	//	*PeerMsgSendReq_Location
+	Body isPeerMsgSendReq_Body `protobuf_oneof:"body"`
 }
 
 func (x *PeerMsgSendReq) Reset() {
@@ -80,17 +94,142 @@ func (x *PeerMsgSendReq) GetReceiver() string {
 	return ""
 }
 
+func (x *PeerMsgSendReq) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *PeerMsgSendReq) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (m *PeerMsgSendReq) GetBody() isPeerMsgSendReq_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetText() *MessageText {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_Text); ok {
+		return x.Text
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetImage() *MessageImage {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_Image); ok {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetAudio() *MessageAudio {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_Audio); ok {
+		return x.Audio
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetVideo() *MessageVideo {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_Video); ok {
+		return x.Video
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetFile() *MessageFile {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_File); ok {
+		return x.File
+	}
+	return nil
+}
+
+func (x *PeerMsgSendReq) GetLocation() *MessageLocation {
+	if x, ok := x.GetBody().(*PeerMsgSendReq_Location); ok {
+		return x.Location
+	}
+	return nil
+}
+
+type isPeerMsgSendReq_Body interface {
+	isPeerMsgSendReq_Body()
+}
+
+type PeerMsgSendReq_Text struct {
+	Text *MessageText `protobuf:"bytes,10,opt,name=text,proto3,oneof"`
+}
+
+type PeerMsgSendReq_Image struct {
+	Image *MessageImage `protobuf:"bytes,11,opt,name=image,proto3,oneof"`
+}
+
+type PeerMsgSendReq_Audio struct {
+	Audio *MessageAudio `protobuf:"bytes,12,opt,name=audio,proto3,oneof"`
+}
+
+type PeerMsgSendReq_Video struct {
+	Video *MessageVideo `protobuf:"bytes,13,opt,name=video,proto3,oneof"`
+}
+
+type PeerMsgSendReq_File struct {
+	File *MessageFile `protobuf:"bytes,14,opt,name=file,proto3,oneof"`
+}
+
+type PeerMsgSendReq_Location struct {
+	Location *MessageLocation `protobuf:"bytes,15,opt,name=location,proto3,oneof"`
+}
+
+func (*PeerMsgSendReq_Text) isPeerMsgSendReq_Body() {}
+
+func (*PeerMsgSendReq_Image) isPeerMsgSendReq_Body() {}
+
+func (*PeerMsgSendReq_Audio) isPeerMsgSendReq_Body() {}
+
+func (*PeerMsgSendReq_Video) isPeerMsgSendReq_Body() {}
+
+func (*PeerMsgSendReq_File) isPeerMsgSendReq_Body() {}
+
+func (*PeerMsgSendReq_Location) isPeerMsgSendReq_Body() {}
+
 var File_peermsgsendreq_proto protoreflect.FileDescriptor
 
 var file_peermsgsendreq_proto_rawDesc = []byte{
 	0x0a, 0x14, 0x70, 0x65, 0x65, 0x72, 0x6d, 0x73, 0x67, 0x73, 0x65, 0x6e, 0x64, 0x72, 0x65, 0x71,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x44, 0x0a, 0x0e, 0x50, 0x65, 0x65, 0x72, 0x4d, 0x73,
-	0x67, 0x53, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64,
-	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72,
-	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x42, 0x0c, 0x5a, 0x0a,
-	0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0f, 0x69, 0x6d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x81, 0x03, 0x0a, 0x0e, 0x50, 0x65, 0x65, 0x72,
+	0x4d, 0x73, 0x67, 0x53, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64,
+	0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x12, 0x22,
+	0x0a, 0x0d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x6d, 0x73, 0x67, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4d, 0x73, 0x67,
+	0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x12, 0x22, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c,
+	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x65, 0x78, 0x74, 0x48, 0x00, 0x52, 0x04,
+	0x74, 0x65, 0x78, 0x74, 0x12, 0x25, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x48, 0x00, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x05, 0x61,
+	0x75, 0x64, 0x69, 0x6f, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x41, 0x75, 0x64, 0x69, 0x6f, 0x48, 0x00, 0x52, 0x05, 0x61, 0x75, 0x64,
+	0x69, 0x6f, 0x12, 0x25, 0x0a, 0x05, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0d, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x56, 0x69, 0x64, 0x65, 0x6f,
+	0x48, 0x00, 0x52, 0x05, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x12, 0x22, 0x0a, 0x04, 0x66, 0x69, 0x6c,
+	0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x46, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x2e, 0x0a,
+	0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x48, 0x00, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x3a, 0x04, 0xa0,
+	0xbb, 0x18, 0x08, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x42, 0x0c, 0x5a, 0x0a, 0x2e,
+	0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -107,14 +246,26 @@ func file_peermsgsendreq_proto_rawDescGZIP() []byte {
 
 var file_peermsgsendreq_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_peermsgsendreq_proto_goTypes = []interface{}{
-	(*PeerMsgSendReq)(nil), // 0: PeerMsgSendReq
+	(*PeerMsgSendReq)(nil),  // 0: PeerMsgSendReq
+	(*MessageText)(nil),     // 1: MessageText
+	(*MessageImage)(nil),    // 2: MessageImage
+	(*MessageAudio)(nil),    // 3: MessageAudio
+	(*MessageVideo)(nil),    // 4: MessageVideo
+	(*MessageFile)(nil),     // 5: MessageFile
+	(*MessageLocation)(nil), // 6: MessageLocation
 }
 var file_peermsgsendreq_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: PeerMsgSendReq.text:type_name -> MessageText
+	2, // 1: PeerMsgSendReq.image:type_name -> MessageImage
+	3, // 2: PeerMsgSendReq.audio:type_name -> MessageAudio
+	4, // 3: PeerMsgSendReq.video:type_name -> MessageVideo
+	5, // 4: PeerMsgSendReq.file:type_name -> MessageFile
+	6, // 5: PeerMsgSendReq.location:type_name -> MessageLocation
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_peermsgsendreq_proto_init() }
@@ -122,6 +273,8 @@ func file_peermsgsendreq_proto_init() {
 	if File_peermsgsendreq_proto != nil {
 		return
 	}
+	file_impackets_proto_init()
+	file_messages_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_peermsgsendreq_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PeerMsgSendReq); i {
@@ -136,6 +289,14 @@ func file_peermsgsendreq_proto_init() {
 			}
 		}
 	}
+	file_peermsgsendreq_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*PeerMsgSendReq_Text)(nil),
+		(*PeerMsgSendReq_Image)(nil),
+		(*PeerMsgSendReq_Audio)(nil),
+		(*PeerMsgSendReq_Video)(nil),
+		(*PeerMsgSendReq_File)(nil),
+		(*PeerMsgSendReq_Location)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{